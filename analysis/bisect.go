@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+const notRun = "not run"
+
+// RunOutcome is the result of a test in a run. Result is "not run" if the
+// test has no result in the run.
+type RunOutcome struct {
+	// Run is the sanity run id
+	Run int
+	// Result indicates whether test passed, failed, was skipped or not run
+	Result string
+}
+
+// Bisection locates the run a test started failing in.
+type Bisection struct {
+	// Test is the name of the test
+	Test string
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// LastPassingRun is the last run test passed in before FirstFailingRun.
+	// Zero if test did not pass in any of the considered runs before failing.
+	LastPassingRun int
+	// FirstFailingRun is the first run of the latest failure window.
+	// Zero if test never failed in the considered runs.
+	FirstFailingRun int
+	// LatestRun is the latest run test was executed (passed or failed) in
+	LatestRun int
+	// LatestResult is the result of the test in LatestRun
+	LatestResult string
+	// Failures is the number of runs, since FirstFailingRun, test failed in
+	Failures int
+	// Passes is the number of runs, since FirstFailingRun, test passed in
+	Passes int
+	// Flapping is true if test passed at least once since FirstFailingRun
+	Flapping bool
+	// Outcomes lists, oldest first, the result of the test in every run from
+	// LastPassingRun (or the first considered run) to the latest run.
+	Outcomes []RunOutcome
+}
+
+// Bisect walks outcomes, which must be sorted oldest first, and locates the
+// latest failure window of test. A failure window ends, going back in time,
+// at the first sequence of stable consecutive passes. Runs where test was
+// skipped or not run are ignored.
+func Bisect(test, env string, outcomes []RunOutcome, stable int) Bisection {
+	b := Bisection{Test: test, Environment: env}
+
+	executed := make([]int, 0, len(outcomes))
+	for i := range outcomes {
+		if outcomes[i].Result == "passed" || outcomes[i].Result == "failed" {
+			executed = append(executed, i)
+		}
+	}
+	if len(executed) == 0 {
+		return b
+	}
+
+	latest := outcomes[executed[len(executed)-1]]
+	b.LatestRun, b.LatestResult = latest.Run, latest.Result
+
+	lastFailure := -1
+	for i := len(executed) - 1; i >= 0; i-- {
+		if outcomes[executed[i]].Result == "failed" {
+			lastFailure = i
+			break
+		}
+	}
+	if lastFailure == -1 {
+		return b
+	}
+
+	// Walk back from the latest failure until stable consecutive passes are met.
+	firstFailure, lastPass, passes := lastFailure, -1, 0
+	for i := lastFailure - 1; i >= 0; i-- {
+		if outcomes[executed[i]].Result == "failed" {
+			firstFailure, lastPass, passes = i, -1, 0
+			continue
+		}
+		if lastPass == -1 {
+			lastPass = i
+		}
+		passes++
+		if passes >= stable {
+			break
+		}
+	}
+
+	b.FirstFailingRun = outcomes[executed[firstFailure]].Run
+	start := 0
+	if lastPass != -1 {
+		b.LastPassingRun = outcomes[executed[lastPass]].Run
+		start = executed[lastPass]
+	}
+
+	for _, i := range executed[firstFailure:] {
+		if outcomes[i].Result == "failed" {
+			b.Failures++
+		} else {
+			b.Passes++
+		}
+	}
+	b.Flapping = b.Passes > 0
+	b.Outcomes = outcomes[start:]
+
+	return b
+}
+
+// LoadOutcomes returns, oldest first, the result of test in each of the latest
+// runs of environment env.
+func LoadOutcomes(ctx context.Context, logger logr.Logger,
+	test, env string, runs int,
+) ([]RunOutcome, error) {
+	ids, err := es_utils.GetAvailableRuns(ctx, env, runs, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]RunOutcome, 0, len(ids))
+	index := make(map[int]int, len(ids))
+	for _, id := range ids {
+		index[id] = len(outcomes)
+		outcomes = append(outcomes, RunOutcome{Run: id, Result: notRun})
+	}
+
+	results, err := es_utils.GetResults(ctx, logger,
+		es_utils.ResultFilter{Environment: env, Test: test, Max: es_utils.MaxQuerySize})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if j, ok := index[results[i].Run]; ok {
+			outcomes[j].Result = results[i].Result
+		}
+	}
+
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].Run < outcomes[j].Run })
+
+	return outcomes, nil
+}
+
+// DisplayBisection displays the runs of the latest failure window of a test
+// followed by a summary.
+func DisplayBisection(b *Bisection) {
+	if b.LatestRun == 0 {
+		fmt.Printf("Test %s has no passed or failed result in the considered %s runs\n", b.Test, b.Environment)
+		return
+	}
+	if b.FirstFailingRun == 0 {
+		fmt.Printf("Test %s never failed in the considered %s runs (latest run %d)\n", b.Test, b.Environment, b.LatestRun)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"RUN", "RESULT", "NOTE"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for _, o := range b.Outcomes {
+		note := ""
+		switch o.Run {
+		case b.LastPassingRun:
+			note = "last passing run"
+		case b.FirstFailingRun:
+			note = "first failing run"
+		}
+		table.Append([]string{strconv.Itoa(o.Run), o.Result, note})
+	}
+
+	table.Render()
+
+	if b.LastPassingRun != 0 {
+		fmt.Printf("Test %s (%s) last passed in run %d and first failed in run %d\n",
+			b.Test, b.Environment, b.LastPassingRun, b.FirstFailingRun)
+	} else {
+		fmt.Printf("Test %s (%s) first failed in run %d (no earlier passing run in the considered runs)\n",
+			b.Test, b.Environment, b.FirstFailingRun)
+	}
+
+	if b.Flapping {
+		fmt.Printf("Test has been flapping since: %d failure(s) and %d pass(es), latest run %d %s\n",
+			b.Failures, b.Passes, b.LatestRun, b.LatestResult)
+	} else {
+		fmt.Printf("Test has failed consistently since: %d failure(s), latest run %d\n",
+			b.Failures, b.LatestRun)
+	}
+}
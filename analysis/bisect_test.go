@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// outcomes returns outcomes of consecutive runs, starting at run 1.
+func outcomes(results ...string) []RunOutcome {
+	o := make([]RunOutcome, len(results))
+	for i, result := range results {
+		o[i] = RunOutcome{Run: i + 1, Result: result}
+	}
+	return o
+}
+
+func TestBisect(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []RunOutcome
+		want     Bisection
+	}{
+		{
+			name:     "never executed",
+			outcomes: outcomes("skipped", notRun),
+			want:     Bisection{},
+		},
+		{
+			name:     "never failed",
+			outcomes: outcomes("passed", "passed"),
+			want:     Bisection{LatestRun: 2, LatestResult: "passed"},
+		},
+		{
+			name:     "started failing",
+			outcomes: outcomes("passed", "passed", "failed", "failed"),
+			want: Bisection{LastPassingRun: 2, FirstFailingRun: 3, LatestRun: 4, LatestResult: "failed",
+				Failures: 2, Outcomes: outcomes("passed", "passed", "failed", "failed")[1:]},
+		},
+		{
+			name:     "single pass does not end the failure window",
+			outcomes: outcomes("passed", "passed", "failed", "passed", "failed", "failed"),
+			want: Bisection{LastPassingRun: 2, FirstFailingRun: 3, LatestRun: 6, LatestResult: "failed",
+				Failures: 3, Passes: 1, Flapping: true,
+				Outcomes: outcomes("passed", "passed", "failed", "passed", "failed", "failed")[1:]},
+		},
+		{
+			name:     "skipped and not run are ignored",
+			outcomes: outcomes("passed", "skipped", "failed", notRun),
+			want: Bisection{LastPassingRun: 1, FirstFailingRun: 3, LatestRun: 3, LatestResult: "failed",
+				Failures: 1, Outcomes: outcomes("passed", "skipped", "failed", notRun)},
+		},
+		{
+			name:     "failing since the first run",
+			outcomes: outcomes("failed", "failed"),
+			want: Bisection{FirstFailingRun: 1, LatestRun: 2, LatestResult: "failed",
+				Failures: 2, Outcomes: outcomes("failed", "failed")},
+		},
+	}
+	for _, tt := range tests {
+		tt.want.Test, tt.want.Environment = "upgrade", "vcs"
+		if got := Bisect("upgrade", "vcs", tt.outcomes, 2); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Bisect() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// TestStatus summarizes how a test behaved over a window of runs.
+type TestStatus string
+
+const (
+	// Passing tests never failed in the window
+	Passing TestStatus = "passing"
+	// Failing tests failed in the latest run they were executed in
+	Failing TestStatus = "failing"
+	// Flaky tests failed in the window but passed in the latest run they
+	// were executed in
+	Flaky TestStatus = "flaky"
+)
+
+// TestHistory is the history of a test, in an environment, over a window of runs.
+type TestHistory struct {
+	// Name is the name of the test
+	Name string
+	// Maintainer is the maintainer of the test in its latest run
+	Maintainer string
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Runs is the number of runs test was executed (passed or failed) in
+	Runs int
+	// Failures is the number of runs test failed in
+	Failures int
+	// LastRun is the latest run test was executed in
+	LastRun int
+	// LastResult is the result of the test in LastRun
+	LastResult string
+	// LastFailedRun is the latest run test failed in. Zero if it never failed.
+	LastFailedRun int
+	// Status summarizes the history
+	Status TestStatus
+}
+
+// BuildHistories groups results by environment and test name. Skipped
+// results are ignored. Histories are sorted by environment and name.
+func BuildHistories(results []es_utils.Result) []TestHistory {
+	type key struct{ env, name string }
+	histories := make(map[key]*TestHistory)
+
+	for i := range results {
+		r := &results[i]
+		if r.Result != "passed" && r.Result != "failed" {
+			continue
+		}
+
+		k := key{env: r.Environment, name: r.Name}
+		h, ok := histories[k]
+		if !ok {
+			h = &TestHistory{Name: r.Name, Environment: r.Environment}
+			histories[k] = h
+		}
+
+		h.Runs++
+		if r.Run >= h.LastRun {
+			h.LastRun, h.LastResult, h.Maintainer = r.Run, r.Result, r.Maintainer
+		}
+		if r.Result == "failed" {
+			h.Failures++
+			if r.Run > h.LastFailedRun {
+				h.LastFailedRun = r.Run
+			}
+		}
+	}
+
+	list := make([]TestHistory, 0, len(histories))
+	for _, h := range histories {
+		switch {
+		case h.LastResult == "failed":
+			h.Status = Failing
+		case h.Failures > 0:
+			h.Status = Flaky
+		default:
+			h.Status = Passing
+		}
+		list = append(list, *h)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Environment != list[j].Environment {
+			return list[i].Environment < list[j].Environment
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+const noMaintainer = "(none)"
+
+// LoadHistories returns histories of all tests over the latest runs of
+// environment env.
+func LoadHistories(ctx context.Context, logger logr.Logger,
+	env string, runs int,
+) ([]TestHistory, error) {
+	ids, err := es_utils.GetAvailableRuns(ctx, env, runs, logger)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	results, err := es_utils.ListResultsForRuns(ctx, logger, env, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildHistories(results), nil
+}
+
+// DisplayOwners displays failing and flaky tests, over the latest runs of the
+// selected environments, grouped by maintainer. If maintainer is set, only
+// tests owned by it are displayed. Quarantined tests are excluded.
+func DisplayOwners(ctx context.Context, logger logr.Logger,
+	maintainer string,
+	vcs, ucs bool,
+	runs int,
+	q *quarantine.List,
+) error {
+	histories := make([]TestHistory, 0)
+	for _, env := range []string{"vcs", "ucs"} {
+		if (env == "vcs" && ucs) || (env == "ucs" && vcs) {
+			continue
+		}
+		h, err := LoadHistories(ctx, logger, env, runs)
+		if err != nil {
+			return err
+		}
+		histories = append(histories, h...)
+	}
+
+	owned := make([]TestHistory, 0)
+	for i := range histories {
+		h := &histories[i]
+		if h.Status == Passing || q.IsQuarantined(h.Name) {
+			continue
+		}
+		if h.Maintainer == "" {
+			h.Maintainer = noMaintainer
+		}
+		if maintainer != "" && h.Maintainer != maintainer {
+			continue
+		}
+		owned = append(owned, *h)
+	}
+
+	sort.SliceStable(owned, func(i, j int) bool {
+		if owned[i].Maintainer != owned[j].Maintainer {
+			return owned[i].Maintainer < owned[j].Maintainer
+		}
+		return owned[i].Status < owned[j].Status
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"MAINTAINER", "ENVIRONMENT", "TEST", "STATUS", "FAILURES", "LAST FAILED RUN"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+
+	for i := range owned {
+		h := &owned[i]
+		table.Append([]string{h.Maintainer, h.Environment, h.Name, string(h.Status),
+			strconv.Itoa(h.Failures) + "/" + strconv.Itoa(h.Runs), strconv.Itoa(h.LastFailedRun)})
+	}
+
+	table.Render()
+
+	return nil
+}
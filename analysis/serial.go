@@ -0,0 +1,262 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// RunSerialTime reports how much of the wall time of a run is spent in
+// serial tests.
+type RunSerialTime struct {
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Run is the sanity run id
+	Run int
+	// WallClock is the time elapsed between the start of the first test and
+	// the end of the last one
+	WallClock time.Duration
+	// Serial is the sum of the durations of serial tests. Serial tests run
+	// one at a time, so this is wall time.
+	Serial time.Duration
+	// SerialTests is the number of serial tests executed in the run
+	SerialTests int
+}
+
+// SerialPercent returns the percentage of the wall time spent in serial tests.
+func (r *RunSerialTime) SerialPercent() float64 {
+	if r.WallClock == 0 {
+		return 0
+	}
+	return float64(r.Serial) * 100 / float64(r.WallClock)
+}
+
+// SerialTest summarizes the history of a test which is serial in the latest
+// run it was executed in.
+type SerialTest struct {
+	// Name is the name of the test
+	Name string
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// SerialRuns is the number of runs test was executed serially in
+	SerialRuns int
+	// SerialFailures is the number of runs test failed in while serial
+	SerialFailures int
+	// ParallelRuns is the number of runs test was executed in parallel in
+	ParallelRuns int
+	// ParallelFailures is the number of runs test failed in while in parallel
+	ParallelFailures int
+	// AverageDuration is the average duration of the serial executions
+	AverageDuration time.Duration
+	// MaxDuration is the longest serial execution
+	MaxDuration time.Duration
+}
+
+// ParallelCandidate returns whether test history suggests it could be run in
+// parallel, and why.
+func (t *SerialTest) ParallelCandidate() (bool, string) {
+	switch {
+	case t.ParallelRuns > 0 && t.ParallelFailures == 0:
+		return true, fmt.Sprintf("passed in all %d parallel run(s)", t.ParallelRuns)
+	case t.ParallelRuns == 0 && t.SerialFailures == 0 && t.SerialRuns >= minStableRuns:
+		return true, fmt.Sprintf("never failed in %d serial run(s)", t.SerialRuns)
+	default:
+		return false, ""
+	}
+}
+
+// minStableRuns is the number of runs a serial test must have passed in to
+// be considered for parallelization without a parallel history.
+const minStableRuns = 3
+
+// SerialStats contains serial vs parallel statistics over a window of runs.
+type SerialStats struct {
+	// Runs reports, most recent first, serial time of each run
+	Runs []RunSerialTime
+	// Tests lists tests serial in the latest run they were executed in,
+	// longest first
+	Tests []SerialTest
+}
+
+// BuildSerialStats computes serial statistics from results of a window of
+// runs. Skipped results are ignored.
+func BuildSerialStats(results []es_utils.Result) *SerialStats {
+	type runKey struct {
+		env string
+		run int
+	}
+	type testKey struct{ env, name string }
+	type testWindow struct {
+		SerialTest
+		lastRun     int
+		lastSerial  bool
+		serialTotal time.Duration
+	}
+
+	runs := make(map[runKey]*RunSerialTime)
+	starts := make(map[runKey]time.Time)
+	ends := make(map[runKey]time.Time)
+	tests := make(map[testKey]*testWindow)
+
+	for i := range results {
+		r := &results[i]
+		if r.Result != "passed" && r.Result != "failed" {
+			continue
+		}
+
+		rk := runKey{env: r.Environment, run: r.Run}
+		rs, ok := runs[rk]
+		if !ok {
+			rs = &RunSerialTime{Environment: r.Environment, Run: r.Run}
+			runs[rk] = rs
+		}
+		if r.Serial {
+			rs.Serial += r.DurationInSecond
+			rs.SerialTests++
+		}
+		if !r.StartTime.IsZero() {
+			if start, ok := starts[rk]; !ok || r.StartTime.Before(start) {
+				starts[rk] = r.StartTime
+			}
+			if end := r.StartTime.Add(r.DurationInSecond); end.After(ends[rk]) {
+				ends[rk] = end
+			}
+		}
+
+		tk := testKey{env: r.Environment, name: r.Name}
+		t, ok := tests[tk]
+		if !ok {
+			t = &testWindow{SerialTest: SerialTest{Name: r.Name, Environment: r.Environment}}
+			tests[tk] = t
+		}
+		if r.Run >= t.lastRun {
+			t.lastRun, t.lastSerial = r.Run, r.Serial
+		}
+		if r.Serial {
+			t.SerialRuns++
+			t.serialTotal += r.DurationInSecond
+			if r.DurationInSecond > t.MaxDuration {
+				t.MaxDuration = r.DurationInSecond
+			}
+			if r.Result == "failed" {
+				t.SerialFailures++
+			}
+		} else {
+			t.ParallelRuns++
+			if r.Result == "failed" {
+				t.ParallelFailures++
+			}
+		}
+	}
+
+	stats := &SerialStats{}
+	for k, rs := range runs {
+		rs.WallClock = ends[k].Sub(starts[k])
+		stats.Runs = append(stats.Runs, *rs)
+	}
+	sort.Slice(stats.Runs, func(i, j int) bool {
+		if stats.Runs[i].Environment != stats.Runs[j].Environment {
+			return stats.Runs[i].Environment > stats.Runs[j].Environment
+		}
+		return stats.Runs[i].Run > stats.Runs[j].Run
+	})
+
+	for _, t := range tests {
+		if !t.lastSerial {
+			continue
+		}
+		t.AverageDuration = t.serialTotal / time.Duration(t.SerialRuns)
+		stats.Tests = append(stats.Tests, t.SerialTest)
+	}
+	sort.Slice(stats.Tests, func(i, j int) bool {
+		if stats.Tests[i].AverageDuration != stats.Tests[j].AverageDuration {
+			return stats.Tests[i].AverageDuration > stats.Tests[j].AverageDuration
+		}
+		return stats.Tests[i].Name < stats.Tests[j].Name
+	})
+
+	return stats
+}
+
+// LoadSerialStats returns serial statistics over the latest runs of the
+// selected environments (both if neither vcs nor ucs is set).
+func LoadSerialStats(ctx context.Context, logger logr.Logger,
+	vcs, ucs bool,
+	runs int,
+) (*SerialStats, error) {
+	results := make([]es_utils.Result, 0)
+	for _, env := range []string{"vcs", "ucs"} {
+		if (env == "vcs" && ucs) || (env == "ucs" && vcs) {
+			continue
+		}
+
+		ids, err := es_utils.GetAvailableRuns(ctx, env, runs, logger)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		r, err := es_utils.ListResultsForRuns(ctx, logger, env, ids)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r...)
+	}
+
+	return BuildSerialStats(results), nil
+}
+
+// DisplaySerialStats displays serial time of each run, the top longest serial
+// tests and the serial tests which could be run in parallel.
+func DisplaySerialStats(stats *SerialStats, top int) {
+	fmt.Printf("Wall time spent in serial tests\n")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENVIRONMENT", "RUN", "WALL-CLOCK", "SERIAL TIME", "SERIAL %", "SERIAL TESTS"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for i := range stats.Runs {
+		r := &stats.Runs[i]
+		table.Append([]string{r.Environment, strconv.Itoa(r.Run), formatDuration(r.WallClock),
+			formatDuration(r.Serial), fmt.Sprintf("%.1f%%", r.SerialPercent()), strconv.Itoa(r.SerialTests)})
+	}
+	table.Render()
+
+	fmt.Printf("\nLongest serial tests\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENVIRONMENT", "TEST", "SERIAL RUNS", "AVERAGE DURATION", "MAX DURATION"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for i := 0; i < len(stats.Tests) && i < top; i++ {
+		t := &stats.Tests[i]
+		table.Append([]string{t.Environment, t.Name, strconv.Itoa(t.SerialRuns),
+			formatDuration(t.AverageDuration), formatDuration(t.MaxDuration)})
+	}
+	table.Render()
+
+	fmt.Printf("\nCandidates for parallel execution\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENVIRONMENT", "TEST", "AVERAGE DURATION", "REASON"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for i := range stats.Tests {
+		t := &stats.Tests[i]
+		if ok, reason := t.ParallelCandidate(); ok {
+			table.Append([]string{t.Environment, t.Name, formatDuration(t.AverageDuration), reason})
+		}
+	}
+	table.Render()
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
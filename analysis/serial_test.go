@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+func TestParallelCandidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		test       SerialTest
+		want       bool
+		wantReason string
+	}{
+		{name: "passed in parallel", test: SerialTest{SerialRuns: 1, SerialFailures: 1, ParallelRuns: 2},
+			want: true, wantReason: "passed in all 2 parallel run(s)"},
+		{name: "failed in parallel", test: SerialTest{SerialRuns: 5, ParallelRuns: 2, ParallelFailures: 1}},
+		{name: "stable serial", test: SerialTest{SerialRuns: minStableRuns},
+			want: true, wantReason: "never failed in 3 serial run(s)"},
+		{name: "too few serial runs", test: SerialTest{SerialRuns: minStableRuns - 1}},
+		{name: "failed serial", test: SerialTest{SerialRuns: 10, SerialFailures: 1}},
+	}
+	for _, tt := range tests {
+		got, reason := tt.test.ParallelCandidate()
+		if got != tt.want || reason != tt.wantReason {
+			t.Errorf("%s: ParallelCandidate() = (%v, %q), want (%v, %q)", tt.name, got, reason, tt.want, tt.wantReason)
+		}
+	}
+}
+
+// runResult returns the result of test name in run of environment vcs.
+func runResult(run int, name, result string, start, minutes int, serial bool) es_utils.Result {
+	r := timed(name, result, start, minutes, serial)
+	r.Environment, r.Run = "vcs", run
+	return r
+}
+
+func TestBuildSerialStats(t *testing.T) {
+	results := []es_utils.Result{
+		// Run 2: 60 minutes wall clock, 30 of them in serial tests.
+		runResult(2, "install", "passed", 0, 20, false),
+		runResult(2, "upgrade", "passed", 20, 20, true),
+		runResult(2, "backup", "failed", 40, 10, true),
+		runResult(2, "scale", "passed", 40, 20, false),
+		runResult(2, "restore", "skipped", 0, 0, true),
+		// Run 1: upgrade ran in parallel, backup was untimed.
+		runResult(1, "install", "passed", 0, 10, false),
+		runResult(1, "upgrade", "passed", 0, 30, false),
+		{Name: "backup", Result: "passed", Environment: "vcs", Run: 1, Serial: true, DurationInSecond: 20 * time.Minute},
+		// Run 3: scale is now serial.
+		runResult(3, "scale", "passed", 0, 15, true),
+	}
+
+	stats := BuildSerialStats(results)
+
+	wantRuns := []RunSerialTime{
+		{Environment: "vcs", Run: 3, WallClock: 15 * time.Minute, Serial: 15 * time.Minute, SerialTests: 1},
+		{Environment: "vcs", Run: 2, WallClock: 60 * time.Minute, Serial: 30 * time.Minute, SerialTests: 2},
+		{Environment: "vcs", Run: 1, WallClock: 30 * time.Minute, Serial: 20 * time.Minute, SerialTests: 1},
+	}
+	if !reflect.DeepEqual(stats.Runs, wantRuns) {
+		t.Errorf("runs are %+v, want %+v", stats.Runs, wantRuns)
+	}
+	for i, want := range []float64{100, 50, 200.0 / 3} {
+		if got := stats.Runs[i].SerialPercent(); got != want {
+			t.Errorf("run %d: serial percent is %g, want %g", stats.Runs[i].Run, got, want)
+		}
+	}
+
+	wantTests := []SerialTest{
+		{Name: "upgrade", Environment: "vcs", SerialRuns: 1, ParallelRuns: 1,
+			AverageDuration: 20 * time.Minute, MaxDuration: 20 * time.Minute},
+		{Name: "backup", Environment: "vcs", SerialRuns: 2, SerialFailures: 1,
+			AverageDuration: 15 * time.Minute, MaxDuration: 20 * time.Minute},
+		{Name: "scale", Environment: "vcs", SerialRuns: 1, ParallelRuns: 1,
+			AverageDuration: 15 * time.Minute, MaxDuration: 15 * time.Minute},
+	}
+	if !reflect.DeepEqual(stats.Tests, wantTests) {
+		t.Errorf("tests are %+v, want %+v", stats.Tests, wantTests)
+	}
+}
+
+func TestSerialPercentNoWallClock(t *testing.T) {
+	// Results with no start time give no wall clock.
+	stats := BuildSerialStats([]es_utils.Result{
+		{Name: "upgrade", Result: "passed", Environment: "vcs", Run: 1, Serial: true, DurationInSecond: time.Hour},
+	})
+	if len(stats.Runs) != 1 || stats.Runs[0].WallClock != 0 || stats.Runs[0].Serial != time.Hour {
+		t.Fatalf("runs are %+v", stats.Runs)
+	}
+	if got := stats.Runs[0].SerialPercent(); got != 0 {
+		t.Errorf("serial percent is %g, want 0", got)
+	}
+}
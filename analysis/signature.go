@@ -0,0 +1,253 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// noFailureMessage is the signature of failures with no message.
+const noFailureMessage = "(no failure message)"
+
+// signatureRules normalize the parts of a failure message which change from
+// one occurrence to another of the same failure. Order matters: timestamps
+// and UUIDs are replaced before the numbers they contain.
+var signatureRules = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`"), "<name>"},
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(ns|µs|us|ms|s|m|h))+\b`), "<duration>"},
+	{regexp.MustCompile(`\b\d+\b`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// Signature returns the normalized first line of a failure message: UUIDs,
+// timestamps, IP addresses, hexadecimal ids, quoted names, durations and
+// numbers are replaced by placeholders, so that occurrences of the same
+// failure in different tests and runs share the same signature.
+func Signature(message string) string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return noFailureMessage
+	}
+	if i := strings.IndexByte(message, '\n'); i != -1 {
+		message = message[:i]
+	}
+
+	for _, rule := range signatureRules {
+		message = rule.re.ReplaceAllString(message, rule.replacement)
+	}
+
+	return strings.TrimSpace(message)
+}
+
+// FailureGroup is a set of failures sharing the same key.
+type FailureGroup struct {
+	// Key is the signature or the test name failures are grouped by
+	Key string
+	// Failures is the number of failures in the group
+	Failures int
+	// Tests are the distinct tests which failed, sorted by name
+	Tests []string
+	// Signatures are the distinct signatures of the failures, sorted
+	Signatures []string
+	// Runs are the distinct environment/run the failures happened in, most
+	// recent first
+	Runs []string
+	// Example is the failure message of the most recent failure
+	Example string
+	// Location is the failure location of the most recent failure
+	Location string
+}
+
+// GroupBy is the key failures are grouped by.
+type GroupBy string
+
+const (
+	// GroupBySignature groups failures by normalized failure message
+	GroupBySignature GroupBy = "signature"
+	// GroupByTest groups failures by test name
+	GroupByTest GroupBy = "test"
+)
+
+// ParseGroupBy validates groupBy.
+func ParseGroupBy(groupBy string) (GroupBy, error) {
+	switch g := GroupBy(strings.ToLower(groupBy)); g {
+	case GroupBySignature, GroupByTest:
+		return g, nil
+	default:
+		return "", fmt.Errorf("unknown group-by %q (valid values are signature and test)", groupBy)
+	}
+}
+
+// GroupFailures groups failed results by groupBy. Groups are sorted by
+// number of failures, largest first.
+func GroupFailures(results []es_utils.Result, groupBy GroupBy) []FailureGroup {
+	type group struct {
+		FailureGroup
+		tests      map[string]bool
+		signatures map[string]bool
+		runs       map[string]bool
+		latest     *es_utils.Result
+	}
+	groups := make(map[string]*group)
+
+	for i := range results {
+		r := &results[i]
+		if r.Result != "failed" {
+			continue
+		}
+
+		signature := Signature(r.FailureMessage)
+		key := signature
+		if groupBy == GroupByTest {
+			key = r.Name
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				FailureGroup: FailureGroup{Key: key},
+				tests:        make(map[string]bool),
+				signatures:   make(map[string]bool),
+				runs:         make(map[string]bool),
+			}
+			groups[key] = g
+		}
+
+		g.Failures++
+		g.tests[r.Name] = true
+		g.signatures[signature] = true
+		g.runs[fmt.Sprintf("%s/%d", r.Environment, r.Run)] = true
+		if g.latest == nil || r.Run > g.latest.Run {
+			g.latest = r
+		}
+	}
+
+	list := make([]FailureGroup, 0, len(groups))
+	for _, g := range groups {
+		g.Tests = sortedKeys(g.tests)
+		g.Signatures = sortedKeys(g.signatures)
+		g.Runs = sortedKeys(g.runs)
+		sort.SliceStable(g.Runs, func(i, j int) bool { return runOf(g.Runs[i]) > runOf(g.Runs[j]) })
+		g.Example = strings.TrimSpace(g.latest.FailureMessage)
+		g.Location = g.latest.FailureLocation
+		list = append(list, g.FailureGroup)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Failures != list[j].Failures {
+			return list[i].Failures > list[j].Failures
+		}
+		return list[i].Key < list[j].Key
+	})
+
+	return list
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runOf returns the run of an environment/run string.
+func runOf(envRun string) int {
+	run, _ := strconv.Atoi(envRun[strings.IndexByte(envRun, '/')+1:])
+	return run
+}
+
+// LoadFailures returns failed results of the latest runs of the selected
+// environments (both if neither vcs nor ucs is set). If test is set, only
+// its failures are returned.
+func LoadFailures(ctx context.Context, logger logr.Logger,
+	test string,
+	vcs, ucs bool,
+	runs int,
+) ([]es_utils.Result, error) {
+	failures := make([]es_utils.Result, 0)
+	for _, env := range []string{"vcs", "ucs"} {
+		if (env == "vcs" && ucs) || (env == "ucs" && vcs) {
+			continue
+		}
+
+		ids, err := es_utils.GetAvailableRuns(ctx, env, runs, logger)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		results, err := es_utils.ListResultsForRuns(ctx, logger, env, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			if results[i].Result == "failed" && (test == "" || results[i].Name == test) {
+				failures = append(failures, results[i])
+			}
+		}
+	}
+
+	return failures, nil
+}
+
+// maxListed is the maximum number of tests or runs listed in a group.
+const maxListed = 5
+
+// DisplayFailureGroups displays failure groups, largest first.
+func DisplayFailureGroups(groups []FailureGroup, groupBy GroupBy) {
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"SIGNATURE", "FAILURES", "TESTS", "RUNS", "LATEST MESSAGE", "LOCATION"}
+	if groupBy == GroupByTest {
+		header = []string{"TEST", "FAILURES", "SIGNATURES", "RUNS", "LATEST MESSAGE", "LOCATION"}
+	}
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for i := range groups {
+		g := &groups[i]
+		second := g.Tests
+		if groupBy == GroupByTest {
+			second = g.Signatures
+		}
+		table.Append([]string{g.Key, strconv.Itoa(g.Failures), truncatedList(second),
+			truncatedList(g.Runs), firstLine(g.Example), g.Location})
+	}
+
+	table.Render()
+}
+
+func truncatedList(items []string) string {
+	if len(items) <= maxListed {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items[:maxListed], "\n") + fmt.Sprintf("\n(+%d more)", len(items)-maxListed)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		return s[:i] + " ..."
+	}
+	return s
+}
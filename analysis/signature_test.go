@@ -0,0 +1,131 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "empty", message: "  \n", want: noFailureMessage},
+		{name: "first line only", message: "timed out\nstack trace\n", want: "timed out"},
+		{
+			name:    "uuid before hex and numbers",
+			message: "pod 123e4567-e89b-12d3-a456-426614174000 not ready",
+			want:    "pod <uuid> not ready",
+		},
+		{
+			name:    "hex before numbers",
+			message: "container deadbeef12 exited with 0x1f",
+			want:    "container <hex> exited with <hex>",
+		},
+		{
+			name:    "timestamps before numbers",
+			message: "at 2024-01-02T03:04:05.123Z and 10:11:12 retry 3",
+			want:    "at <time> and <time> retry <n>",
+		},
+		{
+			name:    "ip address with port",
+			message: "dial tcp 10.0.0.1:443: connection refused",
+			want:    "dial tcp <ip>: connection refused",
+		},
+		{
+			name:    "quoted names",
+			message: "deployment \"web-42\" in namespace 'ns-7' uses `img:1.2`",
+			want:    "deployment <name> in namespace <name> uses <name>",
+		},
+		{
+			name:    "durations before numbers",
+			message: "timed out after 5m30s (poll 250ms), 2 attempts",
+			want:    "timed out after <duration> (poll <duration>), <n> attempts",
+		},
+		{name: "whitespace collapsed", message: "  expected   1\treplica ", want: "expected <n> replica"},
+	}
+	for _, tt := range tests {
+		if got := Signature(tt.message); got != tt.want {
+			t.Errorf("%s: Signature(%q) = %q, want %q", tt.name, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestGroupFailures(t *testing.T) {
+	results := []es_utils.Result{
+		{Name: "upgrade", Result: "failed", Environment: "vcs", Run: 10, FailureMessage: "timed out after 5m"},
+		{Name: "scale", Result: "failed", Environment: "vcs", Run: 12, FailureMessage: "timed out after 10m",
+			FailureLocation: "scale_test.go:42"},
+		{Name: "upgrade", Result: "failed", Environment: "ucs", Run: 11, FailureMessage: "pod \"a\" not found"},
+		{Name: "upgrade", Result: "passed", Environment: "vcs", Run: 13},
+	}
+
+	tests := []struct {
+		groupBy GroupBy
+		want    []FailureGroup
+	}{
+		{
+			groupBy: GroupBySignature,
+			want: []FailureGroup{
+				{
+					Key:        "timed out after <duration>",
+					Failures:   2,
+					Tests:      []string{"scale", "upgrade"},
+					Signatures: []string{"timed out after <duration>"},
+					Runs:       []string{"vcs/12", "vcs/10"},
+					Example:    "timed out after 10m",
+					Location:   "scale_test.go:42",
+				},
+				{
+					Key:        "pod <name> not found",
+					Failures:   1,
+					Tests:      []string{"upgrade"},
+					Signatures: []string{"pod <name> not found"},
+					Runs:       []string{"ucs/11"},
+					Example:    "pod \"a\" not found",
+				},
+			},
+		},
+		{
+			groupBy: GroupByTest,
+			want: []FailureGroup{
+				{
+					Key:        "upgrade",
+					Failures:   2,
+					Tests:      []string{"upgrade"},
+					Signatures: []string{"pod <name> not found", "timed out after <duration>"},
+					Runs:       []string{"ucs/11", "vcs/10"},
+					Example:    "pod \"a\" not found",
+				},
+				{
+					Key:        "scale",
+					Failures:   1,
+					Tests:      []string{"scale"},
+					Signatures: []string{"timed out after <duration>"},
+					Runs:       []string{"vcs/12"},
+					Example:    "timed out after 10m",
+					Location:   "scale_test.go:42",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := GroupFailures(results, tt.groupBy); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GroupFailures(%s) = %+v, want %+v", tt.groupBy, got, tt.want)
+		}
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	for _, groupBy := range []string{"signature", "Test"} {
+		if _, err := ParseGroupBy(groupBy); err != nil {
+			t.Errorf("ParseGroupBy(%q) failed: %v", groupBy, err)
+		}
+	}
+	if _, err := ParseGroupBy("maintainer"); err == nil {
+		t.Error("ParseGroupBy(maintainer) succeeded")
+	}
+}
@@ -0,0 +1,254 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Span is the execution of a test on the timeline of a run.
+type Span struct {
+	// Name is the name of the test
+	Name string
+	// Result indicates whether test passed or failed
+	Result string
+	// Serial indicates whether test was run in serial
+	Serial bool
+	// Start is the time test started
+	Start time.Time
+	// End is the time test ended
+	End time.Time
+	// Critical is true if the span is on the critical path
+	Critical bool
+}
+
+// Duration returns how long the test ran.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Gap is an interval of the run during which no test was running.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns how long the gap lasted.
+func (g *Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// Timeline is the reconstructed execution of the tests of a run.
+type Timeline struct {
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Run is the sanity run id
+	Run int
+	// Start is the time first test started
+	Start time.Time
+	// End is the time last test ended
+	End time.Time
+	// Spans are the executed tests, sorted by start time
+	Spans []Span
+	// Gaps are the intervals no test was running in
+	Gaps []Gap
+	// CriticalPath lists, in execution order, the indexes in Spans of the
+	// tests on the critical path
+	CriticalPath []int
+	// Busy is the time at least one test was running
+	Busy time.Duration
+	// Work is the sum of test durations
+	Work time.Duration
+	// SerialWork is the sum of serial test durations
+	SerialWork time.Duration
+	// MaxParallelism is the maximum number of tests running at the same time
+	MaxParallelism int
+	// Untimed is the number of executed tests with no start time, which are
+	// not on the timeline
+	Untimed int
+}
+
+// WallClock returns the time elapsed between the start of the first test and
+// the end of the last one.
+func (t *Timeline) WallClock() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// Idle returns the time no test was running.
+func (t *Timeline) Idle() time.Duration {
+	return t.WallClock() - t.Busy
+}
+
+// CriticalPathWork returns the sum of durations of the tests on the critical
+// path. The remaining of the wall clock time is spent waiting between them.
+func (t *Timeline) CriticalPathWork() time.Duration {
+	var d time.Duration
+	for _, i := range t.CriticalPath {
+		d += t.Spans[i].Duration()
+	}
+	return d
+}
+
+// BuildTimeline reconstructs the execution of the tests of a run from their
+// start time and duration. Skipped tests are ignored.
+//
+// Results do not record dependencies between tests, so the critical path is
+// built going back from the test which ended last: the predecessor of a test
+// on the path is the test that ended last before it started. This is the
+// chain of tests (and of waits between them) the run wall clock time is
+// made of: shortening anything not on it does not make the run shorter.
+func BuildTimeline(env string, run int, results []es_utils.Result) *Timeline {
+	t := &Timeline{Environment: env, Run: run}
+
+	for i := range results {
+		r := &results[i]
+		if r.Result != "passed" && r.Result != "failed" {
+			continue
+		}
+		if r.StartTime.IsZero() {
+			t.Untimed++
+			continue
+		}
+		t.Spans = append(t.Spans, Span{
+			Name:   r.Name,
+			Result: r.Result,
+			Serial: r.Serial,
+			Start:  r.StartTime,
+			End:    r.StartTime.Add(r.DurationInSecond),
+		})
+	}
+	if len(t.Spans) == 0 {
+		return t
+	}
+
+	sort.SliceStable(t.Spans, func(i, j int) bool {
+		if !t.Spans[i].Start.Equal(t.Spans[j].Start) {
+			return t.Spans[i].Start.Before(t.Spans[j].Start)
+		}
+		return t.Spans[i].Name < t.Spans[j].Name
+	})
+
+	t.Start = t.Spans[0].Start
+	for i := range t.Spans {
+		s := &t.Spans[i]
+		t.Work += s.Duration()
+		if s.Serial {
+			t.SerialWork += s.Duration()
+		}
+		if s.End.After(t.End) {
+			t.End = s.End
+		}
+	}
+
+	t.buildGaps()
+	t.computeParallelism()
+	t.buildCriticalPath()
+
+	return t
+}
+
+// buildGaps merges spans into busy intervals and records gaps between them.
+func (t *Timeline) buildGaps() {
+	busyStart, busyEnd := t.Spans[0].Start, t.Spans[0].End
+	for i := 1; i < len(t.Spans); i++ {
+		s := &t.Spans[i]
+		if s.Start.After(busyEnd) {
+			t.Busy += busyEnd.Sub(busyStart)
+			t.Gaps = append(t.Gaps, Gap{Start: busyEnd, End: s.Start})
+			busyStart, busyEnd = s.Start, s.End
+			continue
+		}
+		if s.End.After(busyEnd) {
+			busyEnd = s.End
+		}
+	}
+	t.Busy += busyEnd.Sub(busyStart)
+}
+
+// computeParallelism sweeps span boundaries to find the maximum number of
+// tests running at the same time.
+func (t *Timeline) computeParallelism() {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	events := make([]event, 0, 2*len(t.Spans))
+	for i := range t.Spans {
+		events = append(events, event{at: t.Spans[i].Start, delta: 1}, event{at: t.Spans[i].End, delta: -1})
+	}
+	// A test ending when another starts does not overlap with it.
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].delta < events[j].delta
+	})
+
+	running := 0
+	for _, e := range events {
+		running += e.delta
+		if running > t.MaxParallelism {
+			t.MaxParallelism = running
+		}
+	}
+}
+
+// buildCriticalPath walks back from the test which ended last, each time
+// moving to the test that ended last before the current one started.
+func (t *Timeline) buildCriticalPath() {
+	current := -1
+	for i := range t.Spans {
+		if current == -1 || t.Spans[i].End.After(t.Spans[current].End) {
+			current = i
+		}
+	}
+
+	path := []int{current}
+	for {
+		previous := -1
+		for i := range t.Spans {
+			s := &t.Spans[i]
+			if i == current || s.End.After(t.Spans[current].Start) {
+				continue
+			}
+			if previous == -1 || s.End.After(t.Spans[previous].End) {
+				previous = i
+			}
+		}
+		if previous == -1 {
+			break
+		}
+		path = append(path, previous)
+		current = previous
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for _, i := range path {
+		t.Spans[i].Critical = true
+	}
+	t.CriticalPath = path
+}
+
+// LoadTimeline returns the timeline of run in environment env.
+func LoadTimeline(ctx context.Context, logger logr.Logger,
+	env string, run int,
+) (*Timeline, error) {
+	results, err := es_utils.GetResults(ctx, logger,
+		es_utils.ResultFilter{Environment: env, Run: strconv.Itoa(run), Max: es_utils.MaxQuerySize})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w for run %d in environment %s", es_utils.ErrNoResults, run, env)
+	}
+
+	return BuildTimeline(env, run, results), nil
+}
@@ -0,0 +1,301 @@
+package analysis
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var timelineTemplate = htmltemplate.Must(htmltemplate.ParseFS(templatesFS, "templates/timeline.html.tmpl"))
+
+// TimelineFormat is the format a timeline is rendered in.
+type TimelineFormat string
+
+const (
+	// TimelineASCII renders a Gantt-style chart for terminals
+	TimelineASCII TimelineFormat = "ascii"
+	// TimelineSVG renders a standalone SVG image
+	TimelineSVG TimelineFormat = "svg"
+	// TimelineHTML renders an HTML page embedding the SVG image
+	TimelineHTML TimelineFormat = "html"
+)
+
+// ParseTimelineFormat validates format.
+func ParseTimelineFormat(format string) (TimelineFormat, error) {
+	switch f := TimelineFormat(strings.ToLower(format)); f {
+	case TimelineASCII, TimelineSVG, TimelineHTML:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (valid values are ascii, svg and html)", format)
+	}
+}
+
+// WriteTimeline renders t in format to w. width is the number of columns of
+// the ASCII chart.
+func WriteTimeline(w io.Writer, t *Timeline, format TimelineFormat, width int) error {
+	switch format {
+	case TimelineSVG:
+		_, err := io.WriteString(w, timelineSVG(t))
+		return err
+	case TimelineHTML:
+		// Test names are escaped by timelineSVG.
+		return timelineTemplate.Execute(w, struct {
+			*Timeline
+			Summary  []string
+			SVG      htmltemplate.HTML
+			Critical []criticalStep
+		}{
+			Timeline: t,
+			Summary:  timelineSummary(t),
+			SVG:      htmltemplate.HTML(timelineSVG(t)),
+			Critical: criticalSteps(t),
+		})
+	default:
+		_, err := io.WriteString(w, timelineASCII(t, width))
+		return err
+	}
+}
+
+// criticalStep is a test on the critical path, formatted for display.
+type criticalStep struct {
+	Name       string
+	Result     string
+	Serial     bool
+	Offset     string
+	Duration   string
+	WaitBefore string
+}
+
+// criticalSteps returns the tests on the critical path, in execution order,
+// with the time waited, since the previous test on the path ended, before
+// each of them started.
+func criticalSteps(t *Timeline) []criticalStep {
+	steps := make([]criticalStep, 0, len(t.CriticalPath))
+	previousEnd := t.Start
+	for _, i := range t.CriticalPath {
+		s := &t.Spans[i]
+		steps = append(steps, criticalStep{
+			Name:       s.Name,
+			Result:     s.Result,
+			Serial:     s.Serial,
+			Offset:     formatDuration(s.Start.Sub(t.Start)),
+			Duration:   formatDuration(s.Duration()),
+			WaitBefore: formatDuration(s.Start.Sub(previousEnd)),
+		})
+		previousEnd = s.End
+	}
+	return steps
+}
+
+// timelineSummary returns the lines explaining where the wall clock time of
+// the run went.
+func timelineSummary(t *Timeline) []string {
+	lines := []string{
+		fmt.Sprintf("Wall-clock %s: busy %s, idle %s (%d gap(s))",
+			formatDuration(t.WallClock()), formatDuration(t.Busy), formatDuration(t.Idle()), len(t.Gaps)),
+		fmt.Sprintf("Test work %s, of which serial %s; max parallelism %d",
+			formatDuration(t.Work), formatDuration(t.SerialWork), t.MaxParallelism),
+		fmt.Sprintf("Critical path: %d test(s), %s of test work and %s waiting",
+			len(t.CriticalPath), formatDuration(t.CriticalPathWork()),
+			formatDuration(t.WallClock()-t.CriticalPathWork())),
+	}
+	if t.Untimed > 0 {
+		lines = append(lines, fmt.Sprintf("%d test(s) without start time are not displayed", t.Untimed))
+	}
+	return lines
+}
+
+// timelineASCII renders one row per test, in start order, with a bar
+// spanning the columns the test was running in, followed by a row marking
+// idle gaps.
+func timelineASCII(t *Timeline, width int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Run %d (%s)\n", t.Run, t.Environment)
+	for _, line := range timelineSummary(t) {
+		fmt.Fprintln(&b, line)
+	}
+	if len(t.Spans) == 0 {
+		return b.String()
+	}
+	fmt.Fprintln(&b)
+
+	nameWidth := len("idle")
+	for i := range t.Spans {
+		if l := len(t.Spans[i].Name) + 1; l > nameWidth {
+			nameWidth = l
+		}
+	}
+
+	wall := t.WallClock()
+	column := func(at time.Time, round func(float64) float64) int {
+		if wall == 0 {
+			return 0
+		}
+		c := int(round(float64(at.Sub(t.Start)) * float64(width) / float64(wall)))
+		if c > width {
+			c = width
+		}
+		return c
+	}
+	bar := func(start, end time.Time, fill byte) string {
+		row := bytes.Repeat([]byte{' '}, width)
+		from, to := column(start, math.Floor), column(end, math.Ceil)
+		if to <= from {
+			to = from + 1
+		}
+		if to > width {
+			from, to = width-1, width
+		}
+		for i := from; i < to; i++ {
+			row[i] = fill
+		}
+		return string(row)
+	}
+
+	// Axis with a label every 10 columns.
+	axis := bytes.Repeat([]byte{' '}, width)
+	for c := 0; c < width; c += 10 {
+		label := formatDuration(time.Duration(float64(wall) * float64(c) / float64(width)))
+		copy(axis[c:], label)
+	}
+	fmt.Fprintf(&b, "  %-*s  %s\n", nameWidth, "", strings.TrimRight(string(axis), " "))
+
+	for i := range t.Spans {
+		s := &t.Spans[i]
+		marker, name, fill := " ", s.Name, byte('#')
+		if s.Critical {
+			marker = ">"
+		}
+		if s.Serial {
+			name += "*"
+		}
+		if s.Result == "failed" {
+			fill = 'X'
+		}
+		fmt.Fprintf(&b, "%s %-*s |%s| %s %s\n", marker, nameWidth, name, bar(s.Start, s.End, fill),
+			formatDuration(s.Duration()), s.Result)
+	}
+
+	if len(t.Gaps) > 0 {
+		row := []byte(strings.Repeat(" ", width))
+		for i := range t.Gaps {
+			gap := bar(t.Gaps[i].Start, t.Gaps[i].End, '.')
+			for c := range row {
+				if gap[c] == '.' {
+					row[c] = '.'
+				}
+			}
+		}
+		fmt.Fprintf(&b, "  %-*s |%s| %s\n", nameWidth, "idle", string(row), formatDuration(t.Idle()))
+	}
+
+	fmt.Fprintf(&b, "\n# passed  X failed  . idle  > critical path  * serial\n")
+
+	fmt.Fprintf(&b, "\nCritical path:\n")
+	for _, step := range criticalSteps(t) {
+		name := step.Name
+		if step.Serial {
+			name += "*"
+		}
+		fmt.Fprintf(&b, "  +%-8s %-*s %-8s %s (waited %s)\n",
+			step.Offset, nameWidth, name, step.Duration, step.Result, step.WaitBefore)
+	}
+
+	return b.String()
+}
+
+const (
+	svgLabelWidth = 260
+	svgChartWidth = 900
+	svgRowHeight  = 20
+	svgAxisHeight = 30
+)
+
+// timelineSVG renders the timeline as a standalone SVG image: one row per
+// test, idle gaps shaded across all rows and the critical path outlined.
+func timelineSVG(t *Timeline) string {
+	var b strings.Builder
+
+	height := svgAxisHeight + svgRowHeight*(len(t.Spans)+1)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n",
+		svgLabelWidth+svgChartWidth+20, height)
+	fmt.Fprintf(&b, `<title>Run %d (%s) timeline</title>`+"\n", t.Run, escape(t.Environment))
+
+	wall := t.WallClock().Seconds()
+	x := func(at time.Time) float64 {
+		if wall == 0 {
+			return svgLabelWidth
+		}
+		return svgLabelWidth + at.Sub(t.Start).Seconds()*svgChartWidth/wall
+	}
+
+	for i := range t.Gaps {
+		g := &t.Gaps[i]
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="#eeeeee"><title>idle %s</title></rect>`+"\n",
+			x(g.Start), svgAxisHeight, x(g.End)-x(g.Start), height-svgAxisHeight, formatDuration(g.Duration()))
+	}
+
+	step := tickStep(t.WallClock())
+	for d := time.Duration(0); d <= t.WallClock(); d += step {
+		tx := x(t.Start.Add(d))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#cccccc"/>`+"\n", tx, svgAxisHeight-5, tx, height)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", tx, svgAxisHeight-10, formatDuration(d))
+	}
+
+	for i := range t.Spans {
+		s := &t.Spans[i]
+		y := svgAxisHeight + i*svgRowHeight
+		color := "#4caf50"
+		if s.Result == "failed" {
+			color = "#e53935"
+		}
+		if s.Serial {
+			color = map[string]string{"passed": "#1b5e20", "failed": "#8e0000"}[s.Result]
+		}
+		stroke := ""
+		if s.Critical {
+			stroke = ` stroke="#000000" stroke-width="2"`
+		}
+		label := s.Name
+		if s.Serial {
+			label += "*"
+		}
+
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+			svgLabelWidth-5, y+svgRowHeight-6, escape(label))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"%s><title>%s: %s, started at +%s, took %s</title></rect>`+"\n",
+			x(s.Start), y+3, math.Max(x(s.End)-x(s.Start), 1), svgRowHeight-6, color, stroke,
+			escape(s.Name), s.Result, formatDuration(s.Start.Sub(t.Start)), formatDuration(s.Duration()))
+	}
+
+	legendY := svgAxisHeight + len(t.Spans)*svgRowHeight + svgRowHeight - 6
+	fmt.Fprintf(&b, `<text x="%d" y="%d">passed (light) / failed (red), serial* darker, critical path outlined, idle shaded</text>`+"\n",
+		svgLabelWidth, legendY)
+	b.WriteString("</svg>\n")
+
+	return b.String()
+}
+
+// tickStep returns an axis step giving at most about 12 ticks.
+func tickStep(wall time.Duration) time.Duration {
+	for _, m := range []int{1, 2, 5, 10, 15, 30, 60, 120, 240} {
+		step := time.Duration(m) * time.Minute
+		if wall/step <= 12 {
+			return step
+		}
+	}
+	return 480 * time.Minute
+}
+
+func escape(s string) string {
+	return htmltemplate.HTMLEscapeString(s)
+}
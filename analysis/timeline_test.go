@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+var runStart = time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+
+// timed returns a result of test name which started start minutes after
+// runStart and lasted minutes.
+func timed(name, result string, start, minutes int, serial bool) es_utils.Result {
+	return es_utils.Result{Name: name, Result: result, Serial: serial,
+		StartTime: runStart.Add(time.Duration(start) * time.Minute), DurationInSecond: time.Duration(minutes) * time.Minute}
+}
+
+func TestBuildTimeline(t *testing.T) {
+	minutes := func(m int) time.Duration { return time.Duration(m) * time.Minute }
+
+	tests := []struct {
+		name             string
+		results          []es_utils.Result
+		wantSpans        []string
+		wantGaps         [][2]int
+		wantCriticalPath []int
+		wantWallClock    int
+		wantBusy         int
+		wantWork         int
+		wantSerialWork   int
+		wantParallelism  int
+		wantUntimed      int
+	}{
+		{
+			name: "overlapping",
+			results: []es_utils.Result{timed("c", "passed", 8, 4, true), timed("a", "passed", 0, 10, false),
+				timed("b", "failed", 5, 15, false)},
+			wantSpans: []string{"a", "b", "c"}, wantCriticalPath: []int{1},
+			wantWallClock: 20, wantBusy: 20, wantWork: 29, wantSerialWork: 4, wantParallelism: 3,
+		},
+		{
+			name: "back to back",
+			results: []es_utils.Result{timed("a", "passed", 0, 10, true), timed("b", "passed", 10, 15, true),
+				timed("c", "passed", 25, 5, true)},
+			wantSpans: []string{"a", "b", "c"}, wantCriticalPath: []int{0, 1, 2},
+			wantWallClock: 30, wantBusy: 30, wantWork: 30, wantSerialWork: 30, wantParallelism: 1,
+		},
+		{
+			name: "idle gap",
+			results: []es_utils.Result{timed("a", "passed", 0, 10, false), timed("b", "passed", 15, 5, false),
+				timed("c", "passed", 16, 14, false)},
+			wantSpans: []string{"a", "b", "c"}, wantGaps: [][2]int{{10, 15}}, wantCriticalPath: []int{0, 2},
+			wantWallClock: 30, wantBusy: 25, wantWork: 29, wantParallelism: 2,
+		},
+		{
+			name: "predecessor is the test ending last before the start",
+			results: []es_utils.Result{timed("b", "passed", 0, 8, false), timed("a", "passed", 0, 10, false),
+				timed("c", "passed", 12, 8, false)},
+			wantSpans: []string{"a", "b", "c"}, wantGaps: [][2]int{{10, 12}}, wantCriticalPath: []int{0, 2},
+			wantWallClock: 20, wantBusy: 18, wantWork: 26, wantParallelism: 2,
+		},
+		{
+			name: "untimed and skipped",
+			results: []es_utils.Result{timed("a", "passed", 0, 10, false), timed("b", "skipped", 2, 0, false),
+				{Name: "c", Result: "failed", DurationInSecond: minutes(5)}},
+			wantSpans: []string{"a"}, wantCriticalPath: []int{0},
+			wantWallClock: 10, wantBusy: 10, wantWork: 10, wantParallelism: 1, wantUntimed: 1,
+		},
+		{
+			name:    "nothing executed",
+			results: []es_utils.Result{timed("a", "skipped", 0, 0, false)},
+		},
+	}
+	for _, tt := range tests {
+		tl := BuildTimeline("vcs", 2927, tt.results)
+
+		spans := make([]string, 0)
+		for i := range tl.Spans {
+			spans = append(spans, tl.Spans[i].Name)
+			if critical := containsInt(tt.wantCriticalPath, i); tl.Spans[i].Critical != critical {
+				t.Errorf("%s: span %s critical is %v, want %v", tt.name, tl.Spans[i].Name, tl.Spans[i].Critical, critical)
+			}
+		}
+		if len(tt.wantSpans) == 0 {
+			tt.wantSpans = []string{}
+		}
+		if !reflect.DeepEqual(spans, tt.wantSpans) {
+			t.Errorf("%s: spans are %v, want %v", tt.name, spans, tt.wantSpans)
+		}
+
+		gaps := make([][2]int, 0)
+		for _, g := range tl.Gaps {
+			gaps = append(gaps, [2]int{int(g.Start.Sub(runStart).Minutes()), int(g.End.Sub(runStart).Minutes())})
+		}
+		if len(tt.wantGaps) == 0 {
+			tt.wantGaps = [][2]int{}
+		}
+		if !reflect.DeepEqual(gaps, tt.wantGaps) {
+			t.Errorf("%s: gaps are %v, want %v", tt.name, gaps, tt.wantGaps)
+		}
+
+		if !reflect.DeepEqual(tl.CriticalPath, tt.wantCriticalPath) {
+			t.Errorf("%s: critical path is %v, want %v", tt.name, tl.CriticalPath, tt.wantCriticalPath)
+		}
+
+		for _, d := range []struct {
+			what string
+			got  time.Duration
+			want int
+		}{
+			{"wall clock", tl.WallClock(), tt.wantWallClock},
+			{"busy", tl.Busy, tt.wantBusy},
+			{"idle", tl.Idle(), tt.wantWallClock - tt.wantBusy},
+			{"work", tl.Work, tt.wantWork},
+			{"serial work", tl.SerialWork, tt.wantSerialWork},
+		} {
+			if d.got != minutes(d.want) {
+				t.Errorf("%s: %s is %s, want %s", tt.name, d.what, d.got, minutes(d.want))
+			}
+		}
+		if tl.MaxParallelism != tt.wantParallelism {
+			t.Errorf("%s: max parallelism is %d, want %d", tt.name, tl.MaxParallelism, tt.wantParallelism)
+		}
+		if tl.Untimed != tt.wantUntimed {
+			t.Errorf("%s: untimed is %d, want %d", tt.name, tl.Untimed, tt.wantUntimed)
+		}
+	}
+}
+
+func TestCriticalPathWork(t *testing.T) {
+	tl := BuildTimeline("vcs", 2927, []es_utils.Result{timed("a", "passed", 0, 10, false),
+		timed("b", "passed", 15, 5, false), timed("c", "passed", 16, 14, false)})
+	if got := tl.CriticalPathWork(); got != 24*time.Minute {
+		t.Errorf("critical path work is %s, want 24m", got)
+	}
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+// Package archive exports and imports e2e data as portable archives.
+//
+// An archive is a gzipped JSON lines file. The first line is the manifest,
+// every following line is a document along with its kind:
+//
+//	{"manifest": {"format": 1, "schemaVersion": 1, ...}}
+//	{"kind": "results", "source": {"name": "...", "run": 2927, ...}}
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Format is the version of the archive format.
+const Format = 1
+
+// maxLineSize is the maximum size of a document in an archive.
+const maxLineSize = 16 * 1024 * 1024
+
+// importBatchSize is the number of documents stored at once by Import.
+const importBatchSize = 500
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	// Format is the version of the archive format
+	Format int `json:"format"`
+	// SchemaVersion is the schema version of the exported documents
+	SchemaVersion int `json:"schemaVersion"`
+	// CreatedTime is the time archive was created
+	CreatedTime time.Time `json:"createdTime"`
+	// Environments are the environments whose runs were exported
+	Environments []string `json:"environments"`
+	// Since, if set, is the time runs were exported since
+	Since *time.Time `json:"since,omitempty"`
+	// Run, if set, is the only exported run
+	Run int `json:"run,omitempty"`
+	// Documents is the number of documents of each kind in the archive
+	Documents map[string]int `json:"documents"`
+}
+
+// record is a line of an archive.
+type record struct {
+	Manifest *Manifest       `json:"manifest,omitempty"`
+	Kind     string          `json:"kind,omitempty"`
+	Source   json.RawMessage `json:"source,omitempty"`
+}
+
+// Export writes to path an archive of all kinds of documents selected by
+// selection.
+func Export(ctx context.Context, logger logr.Logger,
+	path string, selection es_utils.DocumentSelection,
+) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:        Format,
+		SchemaVersion: es_utils.SchemaVersion,
+		CreatedTime:   time.Now().UTC().Truncate(time.Second),
+		Environments:  selection.Environments,
+		Run:           selection.Run,
+		Documents:     make(map[string]int),
+	}
+	if !selection.Since.IsZero() {
+		since := selection.Since.UTC().Truncate(time.Second)
+		manifest.Since = &since
+	}
+
+	err := write(path, manifest, func(fn func(kind string, source json.RawMessage) error) error {
+		for _, kind := range es_utils.DocumentKinds() {
+			err := es_utils.ScanDocuments(ctx, logger, kind, selection, func(source json.RawMessage) error {
+				return fn(kind, source)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// write writes to path an archive of manifest and of the documents scan
+// calls fn with, counting them in manifest. The archive is written to a
+// temporary file first, so that path is replaced only by a complete archive.
+func write(path string, manifest *Manifest,
+	scan func(fn func(kind string, source json.RawMessage) error) error,
+) error {
+	// Documents are counted while written, and the manifest comes first:
+	// documents are buffered in a temporary file.
+	body, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.body")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(body.Name())
+	defer body.Close()
+
+	w := bufio.NewWriter(body)
+	encoder := json.NewEncoder(w)
+	err = scan(func(kind string, source json.RawMessage) error {
+		manifest.Documents[kind]++
+		return encoder.Encode(record{Kind: kind, Source: source})
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	gz := gzip.NewWriter(out)
+	err = json.NewEncoder(gz).Encode(record{Manifest: manifest})
+	if err == nil {
+		_, err = io.Copy(gz, body)
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(out.Name(), path)
+}
+
+// Read reads the archive at path and calls fn, if not nil, for each document.
+// The archive is validated: the manifest must come first, with a supported
+// format, every document must be valid for its kind and the number of
+// documents of each kind must match the manifest.
+func Read(path string, fn func(kind string, source json.RawMessage) error) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var manifest *Manifest
+	kinds := make(map[string]bool)
+	for _, kind := range es_utils.DocumentKinds() {
+		kinds[kind] = true
+	}
+	documents := make(map[string]int)
+
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("invalid archive %s, line %d: %w", path, line, err)
+		}
+
+		if line == 1 {
+			if r.Manifest == nil {
+				return nil, fmt.Errorf("invalid archive %s: manifest not found", path)
+			}
+			if r.Manifest.Format != Format {
+				return nil, fmt.Errorf("archive %s has format %d, only format %d is supported",
+					path, r.Manifest.Format, Format)
+			}
+			manifest = r.Manifest
+			continue
+		}
+
+		if !kinds[r.Kind] {
+			return nil, fmt.Errorf("invalid archive %s, line %d: unknown kind of documents %q", path, line, r.Kind)
+		}
+		if _, err := es_utils.DocumentID(r.Kind, r.Source); err != nil {
+			return nil, fmt.Errorf("invalid archive %s, line %d: %w", path, line, err)
+		}
+		documents[r.Kind]++
+		if fn != nil {
+			if err := fn(r.Kind, r.Source); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", path, err)
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("invalid archive %s: manifest not found", path)
+	}
+
+	for kind := range kinds {
+		if documents[kind] != manifest.Documents[kind] {
+			return nil, fmt.Errorf("invalid archive %s: %d %s document(s) found, manifest lists %d (archive truncated?)",
+				path, documents[kind], kind, manifest.Documents[kind])
+		}
+	}
+
+	return manifest, nil
+}
+
+// Import stores the documents of the archive at path. The whole archive is
+// validated before any document is stored. Documents are stored with ids
+// derived from their content (see es_utils.DocumentID): importing the same
+// archive twice stores its documents once. If dryRun is set, the archive is
+// only validated.
+func Import(ctx context.Context, logger logr.Logger, path string, dryRun bool) (*Manifest, error) {
+	manifest, err := Read(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.SchemaVersion > es_utils.SchemaVersion {
+		return nil, fmt.Errorf("archive %s has schema version %d, more recent than version %d supported "+
+			"by this e2e_result. Upgrade e2e_result", path, manifest.SchemaVersion, es_utils.SchemaVersion)
+	}
+	if dryRun {
+		return manifest, nil
+	}
+
+	batches := make(map[string][]json.RawMessage)
+	flush := func(kind string) error {
+		err := es_utils.StoreDocuments(ctx, logger, kind, batches[kind])
+		batches[kind] = batches[kind][:0]
+		return err
+	}
+
+	_, err = Read(path, func(kind string, source json.RawMessage) error {
+		batches[kind] = append(batches[kind], source)
+		if len(batches[kind]) < importBatchSize {
+			return nil
+		}
+		return flush(kind)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for kind := range batches {
+		if err := flush(kind); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// DisplayManifest prints the content of an archive.
+func DisplayManifest(m *Manifest) {
+	fmt.Printf("Archive format %d, schema version %d, created %s\n",
+		m.Format, m.SchemaVersion, m.CreatedTime.Format(time.RFC3339))
+
+	selection := fmt.Sprintf("Environments: %s", strings.Join(m.Environments, ", "))
+	if m.Run > 0 {
+		selection += fmt.Sprintf(", run %d", m.Run)
+	}
+	if m.Since != nil {
+		selection += fmt.Sprintf(", runs since %s", m.Since.Format(time.RFC3339))
+	}
+	fmt.Println(selection)
+
+	for _, kind := range es_utils.DocumentKinds() {
+		fmt.Printf("  %-8s %d document(s)\n", kind, m.Documents[kind])
+	}
+}
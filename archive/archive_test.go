@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// document is a document of an archive.
+type document struct {
+	kind   string
+	source string
+}
+
+var testDocuments = []document{
+	{kind: es_utils.ResultDocuments, source: `{"name":"upgrade","result":"failed","environment":"vcs","run":2927}`},
+	{kind: es_utils.ResultDocuments, source: `{"name":"install","result":"passed","environment":"vcs","run":2927}`},
+	{kind: es_utils.ReportDocuments, source: `{"type":"deploy","name":"cluster","environment":"vcs","run":2927}`},
+	{kind: es_utils.UsageDocuments, source: `{"name":"controller","memory":900,"environment":"vcs","run":2927}`},
+	{kind: es_utils.RunInfoDocuments, source: `{"environment":"vcs","run":2927,"branch":"main"}`},
+}
+
+func testManifest() *Manifest {
+	return &Manifest{
+		Format:        Format,
+		SchemaVersion: es_utils.SchemaVersion,
+		CreatedTime:   time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC),
+		Environments:  []string{"vcs"},
+		Run:           2927,
+		Documents:     make(map[string]int),
+	}
+}
+
+// writeDocuments writes an archive of manifest and documents.
+func writeDocuments(t *testing.T, manifest *Manifest, documents []document) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "run-2927.jsonl.gz")
+	err := write(path, manifest, func(fn func(kind string, source json.RawMessage) error) error {
+		for _, d := range documents {
+			if err := fn(d.kind, json.RawMessage(d.source)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := writeDocuments(t, testManifest(), testDocuments)
+
+	read := make([]document, 0)
+	manifest, err := Read(path, func(kind string, source json.RawMessage) error {
+		read = append(read, document{kind: kind, source: string(source)})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(read, testDocuments) {
+		t.Errorf("read documents %v, want %v", read, testDocuments)
+	}
+	want := testManifest()
+	want.Documents = map[string]int{"results": 2, "reports": 1, "usage": 1, "runs": 1}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("manifest is %+v, want %+v", manifest, want)
+	}
+
+	// Temporary files are removed.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("directory contains %v, want only the archive", entries)
+	}
+}
+
+// writeRaw writes an archive made of lines, bypassing validation.
+func writeRaw(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "invalid.jsonl.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	return path
+}
+
+func TestReadInvalid(t *testing.T) {
+	manifest := `{"manifest":{"format":1,"schemaVersion":1,"documents":{"results":1}}}`
+	result := `{"kind":"results","source":{"name":"upgrade","environment":"vcs","run":2927}}`
+
+	tests := []struct {
+		name    string
+		lines   []string
+		wantErr string
+	}{
+		{name: "manifest not first", lines: []string{result, manifest}, wantErr: "manifest not found"},
+		{name: "unsupported format", lines: []string{`{"manifest":{"format":2}}`},
+			wantErr: "has format 2, only format 1 is supported"},
+		{name: "unknown kind", lines: []string{manifest, `{"kind":"logs","source":{}}`},
+			wantErr: `line 2: unknown kind of documents "logs"`},
+		{name: "document without run", lines: []string{manifest, `{"kind":"results","source":{"environment":"vcs"}}`},
+			wantErr: "line 2: invalid results document: environment and run are required"},
+		{name: "truncated", lines: []string{manifest}, wantErr: "0 results document(s) found, manifest lists 1"},
+		{name: "not JSON", lines: []string{manifest, "{"}, wantErr: "line 2"},
+	}
+	for _, tt := range tests {
+		_, err := Read(writeRaw(t, tt.lines...), nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Read error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package classify
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// DefaultFile is the rules file used unless another one is specified.
+const DefaultFile = "known-issues.yaml"
+
+// Category is the kind of cause of a failure.
+type Category string
+
+const (
+	// Infra failures are caused by the infrastructure tests run on
+	Infra Category = "infra"
+	// Product failures are caused by a bug in the product under test
+	Product Category = "product"
+	// Test failures are caused by a bug in the test itself
+	Test Category = "test"
+	// Unclassified failures match no rule
+	Unclassified Category = "unclassified"
+)
+
+// Rule maps failures to a known cause.
+type Rule struct {
+	// Name identifies the rule
+	Name string `json:"name"`
+	// Category is the kind of cause: infra, product or test
+	Category Category `json:"category"`
+	// Issue is optional. It is the id or URL of the bug tracking the cause.
+	Issue string `json:"issue,omitempty"`
+	// Pattern is a regular expression matched against the failure message
+	Pattern string `json:"pattern"`
+	// Test is optional. If set, rule only applies to tests whose name
+	// matches this regular expression.
+	Test string `json:"test,omitempty"`
+
+	pattern *regexp.Regexp
+	test    *regexp.Regexp
+}
+
+// Rules is the content of a rules file.
+type Rules struct {
+	// Rules is the list of rules. The first rule matching a failure wins.
+	Rules []Rule `json:"rules"`
+}
+
+// Load reads and validates a rules file.
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Rules{}
+	if err := yaml.UnmarshalStrict(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse rules %s: %w", path, err)
+	}
+
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rules %s, entry %d: name is required", path, i+1)
+		}
+		switch rule.Category {
+		case Infra, Product, Test:
+		default:
+			return nil, fmt.Errorf("rules %s, rule %s: unknown category %q (valid values are infra, product and test)",
+				path, rule.Name, rule.Category)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("rules %s, rule %s: pattern is required", path, rule.Name)
+		}
+		if rule.pattern, err = regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("rules %s, rule %s: invalid pattern: %w", path, rule.Name, err)
+		}
+		if rule.Test != "" {
+			if rule.test, err = regexp.Compile(rule.Test); err != nil {
+				return nil, fmt.Errorf("rules %s, rule %s: invalid test: %w", path, rule.Name, err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Match returns the first rule matching the failure of r, nil if none does.
+func (r *Rules) Match(result *es_utils.Result) *Rule {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.test != nil && !rule.test.MatchString(result.Name) {
+			continue
+		}
+		if rule.pattern.MatchString(result.FailureMessage) {
+			return rule
+		}
+	}
+	return nil
+}
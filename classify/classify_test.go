@@ -0,0 +1,99 @@
+package classify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// writeRules writes content to a rules file in a temporary directory and
+// returns its path.
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	return path
+}
+
+const testRules = `rules:
+- name: upgrade-timeout
+  category: product
+  issue: BUG-1
+  pattern: timed out
+  test: ^upgrade
+- name: any-timeout
+  category: infra
+  pattern: timed out
+- name: dns
+  category: infra
+  pattern: no such host
+- name: dns-flaky-test
+  category: test
+  pattern: no such host
+`
+
+func TestMatch(t *testing.T) {
+	rules, err := Load(writeRules(t, testRules))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		test    string
+		message string
+		want    string
+	}{
+		{name: "test scoped rule", test: "upgrade-minor", message: "timed out after 5m", want: "upgrade-timeout"},
+		{name: "test scope not matching", test: "scale", message: "timed out after 5m", want: "any-timeout"},
+		{name: "first match wins", test: "scale", message: "dial: no such host", want: "dns"},
+		{name: "no match", test: "scale", message: "connection refused", want: ""},
+	}
+	for _, tt := range tests {
+		rule := rules.Match(&es_utils.Result{Name: tt.test, FailureMessage: tt.message})
+		got := ""
+		if rule != nil {
+			got = rule.Name
+		}
+		if got != tt.want {
+			t.Errorf("%s: Match(%s, %q) = %q, want %q", tt.name, tt.test, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: testRules},
+		{name: "unknown field", content: "rules:\n- name: a\n  category: infra\n  pattern: x\n  owner: bob\n",
+			wantErr: "unknown field"},
+		{name: "missing name", content: "rules:\n- category: infra\n  pattern: x\n", wantErr: "entry 1: name is required"},
+		{name: "unknown category", content: "rules:\n- name: a\n  category: flaky\n  pattern: x\n",
+			wantErr: `unknown category "flaky"`},
+		{name: "missing pattern", content: "rules:\n- name: a\n  category: infra\n", wantErr: "pattern is required"},
+		{name: "invalid pattern", content: "rules:\n- name: a\n  category: infra\n  pattern: (\n",
+			wantErr: "invalid pattern"},
+		{name: "invalid test", content: "rules:\n- name: a\n  category: infra\n  pattern: x\n  test: '['\n",
+			wantErr: "invalid test"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeRules(t, tt.content))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Load failed: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Load error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package classify
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Classification is a failed result labeled with the rule it matched.
+type Classification struct {
+	// Result is the failed result
+	Result *es_utils.Result
+	// Rule is the rule result matched, nil if unclassified
+	Rule *Rule
+}
+
+// Category returns the category of the failure.
+func (c *Classification) Category() Category {
+	if c.Rule == nil {
+		return Unclassified
+	}
+	return c.Rule.Category
+}
+
+// Report is the classification of a set of failures.
+type Report struct {
+	// Classifications lists failures, most recent run first
+	Classifications []Classification
+	// Counts is the number of failures per category
+	Counts map[Category]int
+}
+
+// Classify labels each failed result with the first rule it matches.
+func Classify(rules *Rules, results []es_utils.Result) *Report {
+	report := &Report{Counts: make(map[Category]int)}
+	for i := range results {
+		r := &results[i]
+		if r.Result != "failed" {
+			continue
+		}
+		c := Classification{Result: r, Rule: rules.Match(r)}
+		report.Classifications = append(report.Classifications, c)
+		report.Counts[c.Category()]++
+	}
+
+	sort.SliceStable(report.Classifications, func(i, j int) bool {
+		a, b := report.Classifications[i].Result, report.Classifications[j].Result
+		if a.Run != b.Run {
+			return a.Run > b.Run
+		}
+		if a.Environment != b.Environment {
+			return a.Environment < b.Environment
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
+
+// Unclassified returns the failures which matched no rule.
+func (r *Report) Unclassified() []es_utils.Result {
+	results := make([]es_utils.Result, 0)
+	for i := range r.Classifications {
+		if r.Classifications[i].Rule == nil {
+			results = append(results, *r.Classifications[i].Result)
+		}
+	}
+	return results
+}
+
+// Display displays the label of each failure followed by the number and
+// percentage of failures per category.
+func (r *Report) Display() {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENVIRONMENT", "RUN", "TEST", "CATEGORY", "RULE", "ISSUE", "FAILURE MESSAGE"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for i := range r.Classifications {
+		c := &r.Classifications[i]
+		rule, issue := "", ""
+		if c.Rule != nil {
+			rule, issue = c.Rule.Name, c.Rule.Issue
+		}
+		message := strings.TrimSpace(c.Result.FailureMessage)
+		if j := strings.IndexByte(message, '\n'); j != -1 {
+			message = message[:j] + " ..."
+		}
+		table.Append([]string{c.Result.Environment, strconv.Itoa(c.Result.Run), c.Result.Name,
+			string(c.Category()), rule, issue, message})
+	}
+	table.Render()
+
+	total := len(r.Classifications)
+	fmt.Printf("\nFailures by category\n")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"CATEGORY", "FAILURES", "PERCENTAGE"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for _, category := range []Category{Infra, Product, Test, Unclassified} {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(r.Counts[category]) * 100 / float64(total)
+		}
+		table.Append([]string{string(category), strconv.Itoa(r.Counts[category]), fmt.Sprintf("%.1f%%", percentage)})
+	}
+	table.Append([]string{"total", strconv.Itoa(total), ""})
+	table.Render()
+}
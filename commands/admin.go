@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/admin"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Admin takes keyword then calls subcommand.
+func Admin(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result admin <command> [<args>...]
+
+    dedupe      delete documents stored more than once.
+    init        create or update Elasticsearch indices and their mappings.
+    prune       delete the data of old runs.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	See 'e2e_result admin <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"admin", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "dedupe":
+		return admin.Dedupe(ctx, arguments)
+	case "init":
+		return admin.Init(ctx, arguments)
+	case "prune":
+		return admin.Prune(ctx, arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
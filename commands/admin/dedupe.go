@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Dedupe deletes documents stored more than once and moves documents to the
+// id derived from their content.
+func Dedupe(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result admin dedupe [--dry-run]
+Options:
+  -h --help    Show this screen.
+     --dry-run  Only display what would be moved and deleted.
+
+Description:
+  The admin dedupe command finds and deletes the documents stored more than once
+  in each index, i.e. results, reports, usage reports and run metadata pushed
+  again for the same run before documents had ids derived from their content.
+  Documents are the same if they share:
+    - results: environment, run and test name;
+    - reports: environment, run, type, subtype and name;
+    - usage reports: environment, run and pod name;
+    - run metadata: environment and run.
+  One copy of each document is kept: the one stored with the id derived from its
+  content if any, the most recent one otherwise. Kept copies, and documents
+  stored once, with another id are moved to the id derived from their content,
+  so that pushing them again replaces them.
+  The number of documents to move and of copies to delete from each index is
+  displayed before anything is changed.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	duplicates, err := es_utils.FindDuplicates(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	es_utils.DisplayDuplicates(duplicates, false)
+
+	moves, extra := 0, 0
+	for i := range duplicates {
+		moves += len(duplicates[i].Moves)
+		extra += len(duplicates[i].Extra)
+	}
+	if moves == 0 && extra == 0 {
+		fmt.Println("No duplicated document")
+		return nil
+	}
+	if parsedArgs["--dry-run"].(bool) {
+		fmt.Printf("Dry run: %d document(s) would be moved and %d deleted\n", moves, extra)
+		return nil
+	}
+
+	if err := es_utils.RemoveDuplicates(ctx, logger, duplicates); err != nil {
+		return err
+	}
+
+	es_utils.DisplayDuplicates(duplicates, true)
+
+	return nil
+}
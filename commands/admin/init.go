@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"context"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Init creates Elasticsearch indices with explicit mappings.
+func Init(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result admin init [--dry-run]
+Options:
+  -h --help    Show this screen.
+     --dry-run  Only display what would be done.
+
+Description:
+  The admin init command creates the indices results, reports, usage reports and
+  run metadata are stored in, with explicit mappings (text with keyword
+  subfields for strings, date fields for times, long run ids, as dynamic mapping
+  would create) and the schema version stored in the mapping metadata.
+  Indices created by an older version of e2e_result, or by Elasticsearch dynamic
+  mapping, are updated in place: missing fields are added and the schema version
+  is set. A field whose type differs cannot be updated in place: the command
+  fails and the index must be reindexed.
+  Other commands check the schema version of the indices they use: they warn if
+  it is outdated and fail if it is more recent than the one they support.
+  Running the command again does nothing if indices are up to date.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	dryRun := parsedArgs["--dry-run"].(bool)
+
+	schemas, err := es_utils.InitIndices(ctx, logger, dryRun)
+	es_utils.DisplayIndexSchemas(schemas, dryRun)
+
+	return err
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Prune deletes the documents of old runs.
+func Prune(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result admin prune (--older-than=<age> | --keep-runs=<int>) [--env=<env>] [--dry-run]
+Options:
+  -h --help              Show this screen.
+     --older-than=<age>  Prune runs older than age (e.g. 180d or 36h) or than a date (2006-01-02).
+     --keep-runs=<int>   Prune all runs but the most recent ones.
+     --env=<env>         Only prune runs of environment vcs or ucs (default is both).
+     --dry-run           Only display what would be deleted.
+
+Description:
+  The admin prune command deletes the results, reports, usage reports and run
+  metadata of old runs. Runs are pruned as a whole, from all indices.
+  With --older-than, pruned runs are the runs all of whose documents, in every
+  index, were produced before the given time: a run is kept as long as its
+  newest document is more recent. Documents with no time are ignored: runs with
+  no time at all are not pruned by age.
+  With --keep-runs, pruned runs are all the runs older than the given number of
+  most recent runs with results.
+  The runs and the number of documents to delete from each index are displayed
+  before any is deleted. With --dry-run, nothing is deleted: the total number of
+  runs and documents which would be deleted is displayed.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	options := es_utils.PruneOptions{Environments: []string{"vcs", "ucs"}}
+
+	if passedOlderThan := parsedArgs["--older-than"]; passedOlderThan != nil {
+		options.OlderThan, err = cmdutil.ParseSince(passedOlderThan.(string), time.Now())
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	if passedKeepRuns := parsedArgs["--keep-runs"]; passedKeepRuns != nil {
+		options.KeepRuns, err = strconv.Atoi(passedKeepRuns.(string))
+		if err != nil || options.KeepRuns <= 0 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--keep-runs must be a positive number")}
+		}
+	}
+
+	if passedEnv := parsedArgs["--env"]; passedEnv != nil {
+		env := strings.ToLower(passedEnv.(string))
+		if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+		}
+		options.Environments = []string{env}
+	}
+
+	plans, err := es_utils.PlanPrune(ctx, logger, options)
+	if err != nil {
+		return err
+	}
+
+	es_utils.DisplayPrunePlan(plans, false)
+
+	total := int64(0)
+	runs := make(map[string]bool)
+	for i := range plans {
+		total += plans[i].Documents
+		for _, run := range plans[i].Runs {
+			runs[fmt.Sprintf("%s/%d", plans[i].Environment, run)] = true
+		}
+	}
+	if total == 0 {
+		fmt.Println("Nothing to prune")
+		return nil
+	}
+	if parsedArgs["--dry-run"].(bool) {
+		fmt.Printf("Dry run: %d run(s), %d document(s) would be deleted\n", len(runs), total)
+		return nil
+	}
+
+	if err := es_utils.Prune(ctx, logger, plans); err != nil {
+		return err
+	}
+
+	es_utils.DisplayPrunePlan(plans, true)
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/analysis"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Bisect locates the run a test started failing in.
+func Bisect(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result bisect --test=<name> --env=<env> [--runs=<int>] [--stable=<int>]
+Options:
+  -h --help               Show this screen.
+     --test=<name>        Test to bisect.
+     --env=<env>          Environment to consider (vcs or ucs).
+     --runs=<int>         Number of latest runs to walk (default is 100)
+     --stable=<int>       Consecutive passes after which a test is considered healthy (default is 3)
+
+Description:
+  The bisect command walks the history of a test, newest run first, and reports
+  the last passing run and the first failing run of its latest failure window,
+  and whether the test has been flapping since. A failure window ends, going
+  back in time, at the first sequence of --stable consecutive passes. Runs
+  between the last passing run and the first failing run are where the
+  regression was introduced.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	test := parsedArgs["--test"].(string)
+
+	env := parsedArgs["--env"].(string)
+	if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+	}
+
+	runs := 100
+	if passedRuns := parsedArgs["--runs"]; passedRuns != nil {
+		runs, err = strconv.Atoi(passedRuns.(string))
+		if err != nil || runs < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--runs must be a positive integer")}
+		}
+	}
+
+	stable := 3
+	if passedStable := parsedArgs["--stable"]; passedStable != nil {
+		stable, err = strconv.Atoi(passedStable.(string))
+		if err != nil || stable < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--stable must be a positive integer")}
+		}
+	}
+
+	outcomes, err := analysis.LoadOutcomes(ctx, logger, test, env, runs)
+	if err != nil {
+		return err
+	}
+
+	b := analysis.Bisect(test, env, outcomes, stable)
+	analysis.DisplayBisection(&b)
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Exit codes returned by e2e_result.
+const (
+	// ExitSuccess is returned when command completed successfully.
+	ExitSuccess = 0
+	// ExitUsage is returned when command line is invalid.
+	ExitUsage = 1
+	// ExitBackendUnreachable is returned when Elasticsearch cannot be reached.
+	ExitBackendUnreachable = 2
+	// ExitFailuresFound is returned when --fail-on-failures is set and
+	// query matched failed tests.
+	ExitFailuresFound = 3
+	// ExitError is returned for any other error (missing index, failed query...).
+	ExitError = 4
+	// ExitGateViolation is returned when at least one gate rule is violated.
+	ExitGateViolation = 5
+)
+
+// ErrFailuresFound is returned by commands when --fail-on-failures is set and
+// query matched failed tests.
+var ErrFailuresFound = errors.New("query matched failed tests")
+
+// ErrGateViolation is returned by the gate command when at least one
+// rule of the policy is violated.
+var ErrGateViolation = errors.New("gate policy violated")
+
+// UsageError is returned when command line arguments are invalid.
+type UsageError struct {
+	// Args are the arguments that were passed
+	Args []string
+	// Err is the parsing error
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf(
+		"invalid option: 'e2e_result %s'. Use flag '--help' to read about a specific subcommand. Error: %v",
+		strings.Join(e.Args, " "),
+		e.Err,
+	)
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ParseArgs parses args according to doc.
+// Help is printed, without exiting, when requested; in that case returned
+// options are empty. Invalid arguments are reported as UsageError.
+func ParseArgs(doc string, args []string) (docopt.Opts, error) {
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  false,
+		SkipHelpFlags: false,
+	}
+
+	parsedArgs, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return nil, &UsageError{Args: args, Err: err}
+	}
+
+	return parsedArgs, nil
+}
+
+// ExitCode returns the exit code matching err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
+	}
+
+	var connErr *es_utils.ConnectionError
+	if errors.As(err, &connErr) {
+		return ExitBackendUnreachable
+	}
+
+	if errors.Is(err, ErrFailuresFound) {
+		return ExitFailuresFound
+	}
+
+	if errors.Is(err, ErrGateViolation) {
+		return ExitGateViolation
+	}
+
+	return ExitError
+}
+
+// ParseEnvironment converts an environment name (vcs or ucs) into the
+// vcs/ucs filters used by es_utils. An empty env selects both.
+func ParseEnvironment(env string) (vcs, ucs bool, err error) {
+	switch strings.ToLower(env) {
+	case "":
+		return false, false, nil
+	case "vcs":
+		return true, false, nil
+	case "ucs":
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("unknown environment %q (valid values are vcs and ucs)", env)
+	}
+}
+
+// ParseSince parses a point in time given either as an age, i.e. a number of
+// days (180d) or a duration (36h) before now, or as a date (2006-01-02) or
+// time (RFC3339).
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid number of days %q", value)
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected an age like 180d or 36h, "+
+			"a date like 2006-01-02 or an RFC3339 time)", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/commands/export"
+)
+
+// Export takes keyword then calls subcommand.
+func Export(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result export <command> [<args>...]
+
+    archive     export results, reports, usage reports and run metadata to a portable archive.
+    prometheus  export metrics of the newest run in Prometheus text format.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	See 'e2e_result export <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"export", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "archive":
+		return export.Archive(ctx, arguments)
+	case "prometheus":
+		return export.Prometheus(ctx, arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
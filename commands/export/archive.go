@@ -0,0 +1,83 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/archive"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Archive writes results, reports, usage reports and run metadata to a
+// portable archive.
+func Archive(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result export archive --out=<file> [--since=<time> | --run=<id>] [--env=<env>]
+Options:
+  -h --help          Show this screen.
+     --out=<file>    Archive to write, i.e. history.jsonl.gz.
+     --since=<time>  Export runs since an age (e.g. 180d) or a date (2006-01-02).
+     --run=<id>      Export only this run.
+     --env=<env>     Only export runs of environment vcs or ucs (default is both).
+
+Description:
+  The export archive command writes results, reports, usage reports and run
+  metadata to a gzipped JSON lines archive, starting with a manifest listing what
+  the archive contains. Archives are imported by 'e2e_result import', to move
+  data between Elasticsearch clusters, seed a local backend or attach the data of
+  a run to a bug report.
+  Runs are exported as a whole. With --since, exported runs are all the runs from
+  the oldest run with a document produced since the given time. By default, all
+  runs are exported.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	selection := es_utils.DocumentSelection{Environments: []string{"vcs", "ucs"}}
+
+	if passedSince := parsedArgs["--since"]; passedSince != nil {
+		selection.Since, err = cmdutil.ParseSince(passedSince.(string), time.Now())
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	if passedRun := parsedArgs["--run"]; passedRun != nil {
+		selection.Run, err = strconv.Atoi(passedRun.(string))
+		if err != nil || selection.Run <= 0 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--run must be a positive number")}
+		}
+	}
+
+	if passedEnv := parsedArgs["--env"]; passedEnv != nil {
+		env := strings.ToLower(passedEnv.(string))
+		if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+		}
+		selection.Environments = []string{env}
+	}
+
+	out := parsedArgs["--out"].(string)
+	manifest, err := archive.Export(ctx, logger, out, selection)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported to %s\n", out)
+	archive.DisplayManifest(manifest)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/metrics"
+)
+
+// Prometheus writes metrics of the newest run in Prometheus text format.
+func Prometheus(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result export prometheus [--out=<file>]
+Options:
+  -h --help               Show this screen.
+     --out=<file>         File to write (default is stdout). File is replaced
+                          atomically so it can be read by the node_exporter
+                          textfile collector.
+
+Description:
+  The export prometheus command writes gauges derived from the newest run, per
+  environment, of results, reports and usage reports. Series are labelled by
+  environment; e2e_latest_run gives the run they come from. The same metrics
+  are served at /metrics by 'e2e_result serve'.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	families, err := metrics.Collect(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	passedOut := parsedArgs["--out"]
+	if passedOut == nil {
+		return metrics.Write(os.Stdout, families)
+	}
+
+	out := passedOut.(string)
+	tmp, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := metrics.Write(tmp, families); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), out)
+}
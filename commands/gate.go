@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/gate"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+// Gate evaluates a quality policy against a run.
+func Gate(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result gate --run=<id> --env=<env> --policy=<file>
+Options:
+  -h --help               Show this screen.
+     --run=<id>           Run to evaluate.
+     --env=<env>          Environment of the run (vcs or ucs).
+     --policy=<file>      YAML file listing the rules the run must satisfy.
+
+Description:
+  The gate command evaluates every rule of the policy against results, reports
+  and usage reports of a run, prints a verdict per rule and exits with code 5
+  if any rule is violated. Quarantined tests are ignored.
+
+  Example of policy:
+
+    rules:
+    - kind: noNewFailures
+    - kind: passRate
+      minPassRate: 98
+    - kind: reportDuration
+      reportType: ClusterReady
+      reportSubType: cp:3-worker:3
+      percentile: 90
+      maxMinutes: 40
+    - kind: podUsage
+      resource: memory
+      maxPercentOfLimit: 90
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	run, err := strconv.Atoi(parsedArgs["--run"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	env := parsedArgs["--env"].(string)
+	if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+	}
+
+	policy, err := gate.LoadPolicy(parsedArgs["--policy"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	q, err := quarantine.LoadActive(logger)
+	if err != nil {
+		return err
+	}
+
+	data, err := es_utils.LoadRunData(ctx, logger, env, run)
+	if err != nil {
+		return err
+	}
+	quarantinedFailures := data.ExcludeTests(q.IsQuarantined)
+
+	verdicts := gate.Evaluate(policy, data)
+	gate.DisplayVerdicts(data, verdicts)
+
+	if len(quarantinedFailures) > 0 {
+		fmt.Printf("Failures of quarantined tests ignored: %s\n", strings.Join(quarantinedFailures, ", "))
+	}
+
+	if gate.Violated(verdicts) {
+		return cmdutil.ErrGateViolation
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/archive"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Import stores the content of an archive written by export.
+func Import(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result import <file> [--dry-run]
+Options:
+  -h --help               Show this screen.
+     --dry-run            Only validate the archive and display its manifest.
+
+Description:
+  The import command stores the results, reports, usage reports and run metadata
+  of an archive written by 'e2e_result export archive'. Missing indices are
+  created with explicit mappings.
+  The whole archive is validated before any document is stored. Documents are
+  stored with ids derived from their content (environment, run and name), so
+  importing the same archive again does not duplicate anything.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	path := parsedArgs["<file>"].(string)
+	dryRun := parsedArgs["--dry-run"].(bool)
+
+	manifest, err := archive.Import(ctx, logger, path, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("Archive %s is valid\n", path)
+	} else {
+		fmt.Printf("Imported %s\n", path)
+	}
+	archive.DisplayManifest(manifest)
+
+	return nil
+}
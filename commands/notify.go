@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/notify"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+// Notify sends a run summary to a webhook or by email.
+func Notify(ctx context.Context, args []string) error {
+	if len(args) > 1 && args[1] == "email" {
+		return notifyEmail(ctx, args)
+	}
+	return notifyWebhook(ctx, args)
+}
+
+func notifyWebhook(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result notify --run=<id> --webhook=<url> [--env=<env>] [--format=<format>] [--near-limit=<percent>] [--regression=<percent>] [--dry-run]
+Options:
+  -h --help                Show this screen.
+     --run=<id>            Run to summarize, or latest.
+     --webhook=<url>       Webhook URL the summary is posted to.
+     --env=<env>           Environment of the run, vcs or ucs (default is both).
+     --format=<format>     Payload format: slack or teams (default is slack)
+     --near-limit=<percent> Report pods using more than this percentage of their limit (default is 80)
+     --regression=<percent> Report tests whose duration increased by more than this percentage (default is 50)
+     --dry-run             Print the payload instead of posting it.
+
+Description:
+  The notify command composes a summary of a run (pass/fail counts, tests newly
+  failing since the previous run with their maintainer, duration regressions,
+  slowest reports and pods near their limits) and POSTs it to a Slack or
+  Microsoft Teams webhook.
+  See 'e2e_result notify email --help' to send the summary by email instead.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	format := ""
+	if passedFormat := parsedArgs["--format"]; passedFormat != nil {
+		format = passedFormat.(string)
+	}
+	webhookFormat, err := notify.ParseWebhookFormat(format)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	summaries, err := loadSummaries(ctx, logger, args, parsedArgs)
+	if err != nil {
+		return err
+	}
+
+	payload := notify.Payload(webhookFormat, summaries)
+	if parsedArgs["--dry-run"].(bool) {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(payload)
+	}
+
+	return notify.PostWebhook(ctx, parsedArgs["--webhook"].(string), payload)
+}
+
+func notifyEmail(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result notify email --run=<id> --to=<addr> --smtp=<host:port> [--from=<addr>] [--env=<env>] [--starttls] [--username=<user>] [--password-env=<var>] [--near-limit=<percent>] [--regression=<percent>] [--dry-run]
+Options:
+  -h --help                Show this screen.
+     --run=<id>            Run to summarize, or latest.
+     --to=<addr>           Comma separated list of recipients.
+     --smtp=<host:port>    SMTP server.
+     --from=<addr>         Sender (default is e2e-result@<smtp host>)
+     --env=<env>           Environment of the run, vcs or ucs (default is both).
+     --starttls            Upgrade connection with STARTTLS before sending.
+     --username=<user>     Authenticate (PLAIN) with this user.
+     --password-env=<var>  Environment variable containing the SMTP password (default is SMTP_PASSWORD)
+     --near-limit=<percent> Report pods using more than this percentage of their limit (default is 80)
+     --regression=<percent> Report tests whose duration increased by more than this percentage (default is 50)
+     --dry-run             Print the message instead of sending it.
+
+Description:
+  The notify email command sends an HTML and plain-text digest of a run (result
+  counts per environment, new failures, duration regressions, slowest reports and
+  pods near their limits).
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	config := notify.EmailConfig{
+		SMTPAddr: parsedArgs["--smtp"].(string),
+		StartTLS: parsedArgs["--starttls"].(bool),
+	}
+	for _, to := range strings.Split(parsedArgs["--to"].(string), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			config.To = append(config.To, to)
+		}
+	}
+	if passedFrom := parsedArgs["--from"]; passedFrom != nil {
+		config.From = passedFrom.(string)
+	}
+	if passedUsername := parsedArgs["--username"]; passedUsername != nil {
+		config.Username = passedUsername.(string)
+		passwordEnv := "SMTP_PASSWORD"
+		if passedPasswordEnv := parsedArgs["--password-env"]; passedPasswordEnv != nil {
+			passwordEnv = passedPasswordEnv.(string)
+		}
+		config.Password = os.Getenv(passwordEnv)
+	}
+	if err := config.Validate(); err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	summaries, err := loadSummaries(ctx, logger, args, parsedArgs)
+	if err != nil {
+		return err
+	}
+
+	msg, err := notify.BuildEmail(&config, summaries)
+	if err != nil {
+		return err
+	}
+
+	if parsedArgs["--dry-run"].(bool) {
+		_, err = os.Stdout.Write(msg)
+		return err
+	}
+
+	return notify.SendEmail(&config, msg)
+}
+
+// loadSummaries builds a summary for the run, or latest run, of every
+// selected environment having results.
+func loadSummaries(ctx context.Context, logger logr.Logger,
+	args []string, parsedArgs map[string]interface{},
+) ([]*notify.Summary, error) {
+	runArg := parsedArgs["--run"].(string)
+	run := 0
+	if runArg != "latest" {
+		var err error
+		run, err = strconv.Atoi(runArg)
+		if err != nil {
+			return nil, &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--run must be a run id or latest")}
+		}
+	}
+
+	env := ""
+	if passedEnv := parsedArgs["--env"]; passedEnv != nil {
+		env = passedEnv.(string)
+	}
+	vcs, ucs, err := cmdutil.ParseEnvironment(env)
+	if err != nil {
+		return nil, &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	nearLimit := float64(notify.DefaultNearLimitPercent)
+	if passedNearLimit := parsedArgs["--near-limit"]; passedNearLimit != nil {
+		nearLimit, err = strconv.ParseFloat(passedNearLimit.(string), 64)
+		if err != nil {
+			return nil, &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	regression := float64(notify.DefaultRegressionPercent)
+	if passedRegression := parsedArgs["--regression"]; passedRegression != nil {
+		regression, err = strconv.ParseFloat(passedRegression.(string), 64)
+		if err != nil {
+			return nil, &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	q, err := quarantine.LoadActive(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*notify.Summary, 0)
+	for _, e := range []string{"vcs", "ucs"} {
+		if (e == "vcs" && ucs) || (e == "ucs" && vcs) {
+			continue
+		}
+
+		envRun := run
+		if runArg == "latest" {
+			latest, found, err := es_utils.LatestRun(ctx, logger, e)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			envRun = latest
+		}
+
+		data, err := es_utils.LoadRunData(ctx, logger, e, envRun)
+		if errors.Is(err, es_utils.ErrNoResults) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, notify.BuildSummary(data, q, nearLimit, regression))
+	}
+
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("no results found for run %s", runArg)
+	}
+
+	return summaries, nil
+}
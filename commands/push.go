@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/commands/push"
+)
+
+// Push takes keyword then calls subcommand.
+func Push(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result push <command> [<args>...]
+
+    run-info    store metadata (commit, branch, versions, CI job) of a run.
+    results     store test results of a run.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	See 'e2e_result push <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"push", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "run-info":
+		return push.RunInfo(ctx, arguments)
+	case "results":
+		return push.Results(ctx, arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
@@ -0,0 +1,119 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/ingest"
+)
+
+// Results stores test results of a run.
+func Results(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result push results --run=<id> --env=<env> (--file=<file> | --junit=<file>) [--maintainer=<name>] [--artifacts-dir=<dir> [--artifacts-url=<url>]] [--dry-run]
+Options:
+  -h --help                  Show this screen.
+     --run=<id>              Run results belong to.
+     --env=<env>             Environment of the run (vcs or ucs).
+     --file=<file>           JSON file containing an array of results.
+     --junit=<file>          JUnit XML report, as produced by Ginkgo.
+     --maintainer=<name>     Maintainer of tests with none set.
+     --artifacts-dir=<dir>   Directory containing the artifacts (logs, must-gather
+                             bundles, screenshots...) collected by the tests.
+     --artifacts-url=<url>   URL the artifacts directory is published at (default
+                             is to link to local files).
+     --dry-run               Only print the results which would be stored.
+
+Description:
+  The push results command stores the test results of a run. Failure message and
+  location of failed tests are stored along with results: they are read from the
+  failureMessage and failureLocation fields of the JSON file or extracted from the
+  failure elements of the JUnit report.
+
+  With --artifacts-dir, links to the artifacts of each test are stored too. The
+  artifacts of a test are found by name: all the files in the <dir>/<slug>
+  directory and the files named <slug>.<anything> (e.g. <slug>.log) in <dir>,
+  where slug is the test name lowercased with every sequence of characters other
+  than letters, digits, '_' and '-' replaced by '-'. For instance, artifacts of
+  "Deploy cluster [Serial]" are looked for in <dir>/deploy-cluster-serial/.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	run, err := strconv.Atoi(parsedArgs["--run"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	env := strings.ToLower(parsedArgs["--env"].(string))
+	if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+	}
+
+	var results []es_utils.Result
+	if passedFile := parsedArgs["--file"]; passedFile != nil {
+		results, err = ingest.LoadResults(passedFile.(string))
+	} else {
+		results, err = ingest.LoadJUnit(parsedArgs["--junit"].(string))
+	}
+	if err != nil {
+		return err
+	}
+	ingest.SetRun(results, env, run)
+
+	if passedMaintainer := parsedArgs["--maintainer"]; passedMaintainer != nil {
+		for i := range results {
+			if results[i].Maintainer == "" {
+				results[i].Maintainer = passedMaintainer.(string)
+			}
+		}
+	}
+
+	if passedDir := parsedArgs["--artifacts-dir"]; passedDir != nil {
+		baseURL := ""
+		if passedURL := parsedArgs["--artifacts-url"]; passedURL != nil {
+			baseURL = passedURL.(string)
+		}
+		attached, err := ingest.AttachArtifacts(results, passedDir.(string), baseURL)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Found %d artifact(s) in %s\n", attached, passedDir.(string))
+	}
+
+	if parsedArgs["--dry-run"].(bool) {
+		for i := range results {
+			r := &results[i]
+			fmt.Printf("%s %s/%d %s %.2fm", r.Result, r.Environment, r.Run, r.Name, r.DurationInMinutes)
+			if r.FailureMessage != "" {
+				fmt.Printf(" [%s] %s", r.FailureLocation, strings.SplitN(r.FailureMessage, "\n", 2)[0])
+			}
+			fmt.Println()
+			for _, a := range r.Artifacts {
+				fmt.Printf("    %s: %s\n", a.Name, a.URL)
+			}
+		}
+		return nil
+	}
+
+	if err := es_utils.PushResults(ctx, logger, results); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored %d result(s) of %s run %d\n", len(results), env, run)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// RunInfo stores metadata of a run.
+func RunInfo(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result push run-info --run=<id> --env=<env> [--sha=<sha>] [--branch=<name>] [--component=<name=version>...] [--k8s-version=<version>] [--ci-url=<url>] [--start=<time>] [--end=<time>] [--trigger=<name>]
+Options:
+  -h --help                    Show this screen.
+     --run=<id>                Run the metadata refers to.
+     --env=<env>               Environment of the run (vcs or ucs).
+     --sha=<sha>               Git commit tested.
+     --branch=<name>           Branch the commit belongs to.
+     --component=<name=version>  Version of a deployed component. Can be repeated.
+     --k8s-version=<version>   Kubernetes version of the cluster under test.
+     --ci-url=<url>            URL of the CI job which ran the tests.
+     --start=<time>            Time run started (RFC3339).
+     --end=<time>              Time run ended (RFC3339).
+     --trigger=<name>          What started the run, i.e. nightly, pull-request, manual.
+
+Description:
+  The push run-info command stores metadata of a run. Pushing metadata of the
+  same run again replaces it. Metadata are displayed by 'show run' and 'show runs'.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	info := &es_utils.RunInfo{}
+
+	info.Run, err = strconv.Atoi(parsedArgs["--run"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	info.Environment = strings.ToLower(parsedArgs["--env"].(string))
+	if vcs, ucs, err := cmdutil.ParseEnvironment(info.Environment); err != nil || (!vcs && !ucs) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+	}
+
+	if passedSHA := parsedArgs["--sha"]; passedSHA != nil {
+		info.GitSHA = passedSHA.(string)
+	}
+	if passedBranch := parsedArgs["--branch"]; passedBranch != nil {
+		info.Branch = passedBranch.(string)
+	}
+	if passedVersion := parsedArgs["--k8s-version"]; passedVersion != nil {
+		info.KubernetesVersion = passedVersion.(string)
+	}
+	if passedURL := parsedArgs["--ci-url"]; passedURL != nil {
+		info.CIJobURL = passedURL.(string)
+	}
+	if passedTrigger := parsedArgs["--trigger"]; passedTrigger != nil {
+		info.Trigger = passedTrigger.(string)
+	}
+
+	for _, component := range parsedArgs["--component"].([]string) {
+		parts := strings.SplitN(component, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("invalid --component %q, expected name=version", component)}
+		}
+		if info.ComponentVersions == nil {
+			info.ComponentVersions = make(map[string]string)
+		}
+		info.ComponentVersions[parts[0]] = parts[1]
+	}
+
+	if passedStart := parsedArgs["--start"]; passedStart != nil {
+		info.StartTime, err = time.Parse(time.RFC3339, passedStart.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("invalid --start: %w", err)}
+		}
+	}
+	if passedEnd := parsedArgs["--end"]; passedEnd != nil {
+		info.EndTime, err = time.Parse(time.RFC3339, passedEnd.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("invalid --end: %w", err)}
+		}
+	}
+	if !info.StartTime.IsZero() && !info.EndTime.IsZero() && info.EndTime.Before(info.StartTime) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--end must not be before --start")}
+	}
+
+	if err := es_utils.PushRunInfo(ctx, logger, info); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored metadata of %s run %d\n", info.Environment, info.Run)
+
+	return nil
+}
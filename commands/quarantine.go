@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	docopt "github.com/docopt/docopt-go"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+// Quarantine takes keyword then calls subcommand.
+func Quarantine(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result quarantine <command> [<args>...]
+
+    add         quarantine a test.
+    remove      remove a test from quarantine.
+    list        list quarantined tests.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	Quarantined tests are marked by 'show results' and excluded from failure
+	counts of 'show owners', 'gate', 'notify' and 'triage'. Quarantine entries
+	are stored in the file set with the global --quarantine option
+	(default is quarantine.yaml).
+	See 'e2e_result quarantine <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"quarantine", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "add":
+		return quarantineAdd(arguments)
+	case "remove":
+		return quarantineRemove(arguments)
+	case "list":
+		return quarantineList(arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
+
+func quarantineAdd(args []string) error {
+	doc := `Usage:
+	e2e_result quarantine add --test=<name> --reason=<text> --owner=<name> [--expires=<when>]
+Options:
+  -h --help               Show this screen.
+     --test=<name>        Test to quarantine.
+     --reason=<text>      Why the test is quarantined.
+     --owner=<name>       Who is responsible for fixing the test.
+     --expires=<when>     When quarantine ends: a date (2006-01-02, included)
+                          or a number of days (e.g. 30d). Default is never.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	entry := quarantine.Entry{
+		Test:   parsedArgs["--test"].(string),
+		Reason: parsedArgs["--reason"].(string),
+		Owner:  parsedArgs["--owner"].(string),
+		Added:  now.UTC().Truncate(time.Second),
+	}
+	if passedExpires := parsedArgs["--expires"]; passedExpires != nil {
+		expires, err := parseExpiry(passedExpires.(string), now)
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+		entry.Expires = &expires
+	}
+
+	l, err := quarantine.Load(quarantine.File())
+	if err != nil {
+		return err
+	}
+
+	if l.Add(entry) {
+		fmt.Printf("Updated quarantine of test %s\n", entry.Test)
+	} else {
+		fmt.Printf("Quarantined test %s\n", entry.Test)
+	}
+
+	return l.Save()
+}
+
+func quarantineRemove(args []string) error {
+	doc := `Usage:
+	e2e_result quarantine remove --test=<name>
+Options:
+  -h --help               Show this screen.
+     --test=<name>        Test to remove from quarantine.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	test := parsedArgs["--test"].(string)
+
+	l, err := quarantine.Load(quarantine.File())
+	if err != nil {
+		return err
+	}
+
+	if !l.Remove(test) {
+		return fmt.Errorf("test %s is not quarantined", test)
+	}
+
+	fmt.Printf("Removed test %s from quarantine\n", test)
+	return l.Save()
+}
+
+func quarantineList(args []string) error {
+	doc := `Usage:
+	e2e_result quarantine list [--expired]
+Options:
+  -h --help               Show this screen.
+     --expired            List only entries whose quarantine expired.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	onlyExpired := parsedArgs["--expired"].(bool)
+
+	l, err := quarantine.Load(quarantine.File())
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"TEST", "OWNER", "REASON", "ADDED", "EXPIRES", "STATUS"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	now := time.Now()
+	for i := range l.Entries {
+		e := &l.Entries[i]
+		status, expires := "active", "never"
+		if e.Expired(now) {
+			status = "expired"
+		} else if onlyExpired {
+			continue
+		}
+		if e.Expires != nil {
+			expires = e.Expires.Format("2006-01-02")
+		}
+		table.Append([]string{e.Test, e.Owner, e.Reason, e.Added.Format("2006-01-02"), expires, status})
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// parseExpiry parses a date (2006-01-02) or a number of days from now (30d).
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return time.Time{}, fmt.Errorf("invalid expiry %q", value)
+		}
+		return now.UTC().Truncate(time.Second).AddDate(0, 0, n), nil
+	}
+
+	t, err := quarantine.ParseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q (expected a date like 2006-01-02 or a number of days like 30d)", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/server"
+)
+
+// Serve starts the HTTP API server.
+func Serve(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result serve [--listen=<addr>]
+Options:
+  -h --help               Show this screen.
+     --listen=<addr>      Address to listen on (default is :8080)
+
+Description:
+  The serve command exposes e2e results over HTTP. A web dashboard, showing
+  run history, report durations and usage vs limits, is served at /.
+  All API endpoints return JSON and accept the same filters as the show commands:
+
+    GET /api/v1/results   ?env=vcs|ucs&run=<id>&test=<name>&maintainer=<name>&result=passed|failed|skipped&serial=true|parallel=true&max=<int>
+    GET /api/v1/reports   ?env=vcs|ucs&run=<id>&type=<name>&subtype=<name>&name=<name>&max=<int>
+    GET /api/v1/usage     ?env=vcs|ucs&run=<id>&pod=<name>&max=<int>
+    GET /api/v1/runs      ?env=vcs|ucs&max=<int>
+
+  Metrics of the newest run are served in Prometheus format at /metrics.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	listen := ":8080"
+	if passedListen := parsedArgs["--listen"]; passedListen != nil {
+		listen = passedListen.(string)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return server.New(logger).ListenAndServe(ctx, listen)
+}
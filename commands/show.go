@@ -3,11 +3,10 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
 	docopt "github.com/docopt/docopt-go"
 
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
 	"github.com/gianlucam76/cs-e2e-result/commands/show"
 )
 
@@ -18,8 +17,14 @@ func Show(ctx context.Context, args []string) error {
 
     results     show e2e automatic tagging test result history.
     runs        show list of available (vcs and ucs) runs for which results were collected.
+    run         show metadata of a run.
+    run-summary show counts, durations, slowest tests, reports and top consumers of a run.
     reports     show e2e reports.
     usage       show e2e usage reports.
+    owners      show failing and flaky tests grouped by maintainer.
+    stats       show statistics over the latest runs.
+    timeline    show when each test of a run was running and the critical path.
+    failures    show failures of the latest runs grouped by signature or test.
 
 Options:
 	-h --help      Show this screen.
@@ -29,20 +34,17 @@ Description:
   `
 
 	parser := &docopt.Parser{
-		HelpHandler:   docopt.PrintHelpAndExit,
+		HelpHandler:   docopt.PrintHelpOnly,
 		OptionsFirst:  true,
 		SkipHelpFlags: false,
 	}
 
-	opts, err := parser.ParseArgs(doc, nil, "1.0")
+	opts, err := parser.ParseArgs(doc, args, "1.0")
 	if err != nil {
-		if _, ok := err.(*docopt.UserError); ok {
-			fmt.Printf(
-				"Invalid option: 'e2e_result %s'. Use flag '--help' to read about a specific subcommand.\n",
-				strings.Join(os.Args[1:], " "),
-			)
-		}
-		os.Exit(1)
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
 	}
 
 	command := opts["<command>"].(string)
@@ -53,13 +55,23 @@ Description:
 		return show.ResultHistory(ctx, arguments)
 	case "runs":
 		return show.AvailableRuns(ctx, arguments)
+	case "run":
+		return show.RunInfo(ctx, arguments)
+	case "run-summary":
+		return show.RunSummary(ctx, arguments)
 	case "reports":
 		return show.ReportHistory(ctx, arguments)
 	case "usage":
 		return show.UsageHistory(ctx, arguments)
+	case "owners":
+		return show.OwnersHistory(ctx, arguments)
+	case "stats":
+		return show.Stats(ctx, arguments)
+	case "timeline":
+		return show.Timeline(ctx, arguments)
+	case "failures":
+		return show.Failures(ctx, arguments)
 	default:
-		fmt.Println(doc)
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
 	}
-
-	return nil
 }
@@ -0,0 +1,114 @@
+package show
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/analysis"
+	"github.com/gianlucam76/cs-e2e-result/classify"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Failures displays failures of the latest runs grouped by signature or test.
+func Failures(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show failures [--vcs | --ucs] [--test=<name>] [--runs=<int>] [--group-by=<key>] [--classify] [--rules=<file>]
+Options:
+  -h --help               Show this screen.
+     --vcs                Consider vcs runs only.
+     --ucs                Consider ucs runs only.
+     --test=<name>        Consider failures of a specific test only.
+     --runs=<int>         Number of latest runs to consider (default is 10)
+     --group-by=<key>     Group failures by signature or test (default is signature)
+     --classify           Label each failure with the known issue it matches.
+     --rules=<file>       YAML file mapping failure messages to known issues (default is known-issues.yaml)
+
+Description:
+  The show failures command clusters the failures of the latest runs. The
+  signature of a failure is the first line of its message with UUIDs, timestamps,
+  IP addresses, hexadecimal ids, quoted names, durations and numbers replaced by
+  placeholders, so that failures sharing one root cause are grouped across tests
+  and runs.
+
+  With --classify, each failure is labeled with the first rule of the rules file
+  whose pattern matches its message, failures are counted by category (infra,
+  product, test) and the unclassified remainder is grouped as above.
+
+  Example of rules file:
+
+    rules:
+    - name: cloudstack-api-timeout
+      category: infra
+      pattern: 'CloudStack API error 530 .* timed out'
+    - name: upgrade-pod-not-running
+      category: product
+      issue: PROJ-1234
+      pattern: 'Expected pod .* to be Running'
+      test: '^Upgrade'
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	vcs := parsedArgs["--vcs"].(bool)
+	ucs := parsedArgs["--ucs"].(bool)
+
+	test := ""
+	if passedTest := parsedArgs["--test"]; passedTest != nil {
+		test = passedTest.(string)
+	}
+
+	runs := 10
+	if passedRuns := parsedArgs["--runs"]; passedRuns != nil {
+		runs, err = strconv.Atoi(passedRuns.(string))
+		if err != nil || runs < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--runs must be a positive integer")}
+		}
+	}
+
+	groupBy := analysis.GroupBySignature
+	if passedGroupBy := parsedArgs["--group-by"]; passedGroupBy != nil {
+		groupBy, err = analysis.ParseGroupBy(passedGroupBy.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	var rules *classify.Rules
+	if parsedArgs["--classify"].(bool) {
+		path := classify.DefaultFile
+		if passedRules := parsedArgs["--rules"]; passedRules != nil {
+			path = passedRules.(string)
+		}
+		rules, err = classify.Load(path)
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	failures, err := analysis.LoadFailures(ctx, logger, test, vcs, ucs, runs)
+	if err != nil {
+		return err
+	}
+
+	if rules != nil {
+		report := classify.Classify(rules, failures)
+		report.Display()
+
+		failures = report.Unclassified()
+		fmt.Printf("\nUnclassified failures\n")
+	}
+
+	analysis.DisplayFailureGroups(analysis.GroupFailures(failures, groupBy), groupBy)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package show
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/analysis"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+// OwnersHistory displays failing and flaky tests grouped by maintainer.
+func OwnersHistory(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show owners [--vcs | --ucs] [--maintainer=<name>] [--runs=<int>]
+Options:
+  -h --help               Show this screen.
+     --vcs                Consider only vcs runs.
+     --ucs                Consider only ucs runs.
+     --maintainer=<name>  Show only tests maintained by name.
+     --runs=<int>         Number of latest runs to consider (default is 10)
+
+Description:
+  The show owners command groups failing and flaky tests by maintainer.
+  A test is failing if it failed in the latest run it was executed in, flaky
+  if it failed in at least one of the considered runs but passed in the latest.
+  Quarantined tests are not displayed.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	vcs := parsedArgs["--vcs"].(bool)
+	ucs := parsedArgs["--ucs"].(bool)
+
+	maintainer := ""
+	if passedMaintainer := parsedArgs["--maintainer"]; passedMaintainer != nil {
+		maintainer = passedMaintainer.(string)
+	}
+
+	runs := 10
+	if passedRuns := parsedArgs["--runs"]; passedRuns != nil {
+		runs, err = strconv.Atoi(passedRuns.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	q, err := quarantine.LoadActive(logger)
+	if err != nil {
+		return err
+	}
+
+	return analysis.DisplayOwners(context.TODO(), logger, maintainer, vcs, ucs, runs, q)
+}
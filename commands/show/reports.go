@@ -2,20 +2,19 @@ package show
 
 import (
 	"context"
-	"fmt"
 	"strconv"
-	"strings"
 
-	docopt "github.com/docopt/docopt-go"
 	"k8s.io/klog/v2/klogr"
 
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
 	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/slo"
 )
 
 // ReportHistory displays information about e2e sanity entries.
 func ReportHistory(ctx context.Context, args []string) error {
 	doc := `Usage:
-	e2e_result show reports [--vcs | --ucs] [--run=<id>] [--type=<name>] [--subtype=<name>] [--name=<name>] [--max=<int>]
+	e2e_result show reports [--vcs | --ucs] [--run=<id>] [--type=<name>] [--subtype=<name>] [--name=<name>] [--max=<int>] [--check-slo=<file>] [--output=<format>]
 Options:
   -h --help               Show this screen.
      --vcs                Show e2e test results in vcs run.
@@ -25,18 +24,23 @@ Options:
      --type=<name>        Show history for a report type.
      --sybtype=<name>     Show history for a report subtype.
      --name=<name>        Show history of a specific reports.
+     --check-slo=<file>   Mark each report OK/WARN/BREACH against the thresholds in file.
+     --output=<format>    Output format: table, json or csv (default is table)
 
 Description:
   The show reports command shows information about e2e reports.
+
+  Example of thresholds file:
+
+    warnPercent: 90
+    thresholds:
+    - type: ClusterReady
+      subType: cp:3-worker:3
+      maxMinutes: 15
 `
-	parsedArgs, err := docopt.ParseArgs(doc, nil, "1.0")
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
 	if err != nil {
-		fmt.Println(err)
-		return fmt.Errorf(
-			"invalid option: 'e2e_result %s'. Use flag '--help' to read about a specific subcommand. Error: %v",
-			strings.Join(args, " "),
-			err,
-		)
+		return err
 	}
 	if len(parsedArgs) == 0 {
 		return nil
@@ -71,9 +75,27 @@ Description:
 	if passedMax := parsedArgs["--max"]; passedMax != nil {
 		max, err = strconv.Atoi(passedMax.(string))
 		if err != nil {
-			return err
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	var thresholds *slo.Thresholds
+	if passedThresholds := parsedArgs["--check-slo"]; passedThresholds != nil {
+		thresholds, err = slo.Load(passedThresholds.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
 		}
 	}
 
-	return es_utils.DisplayReport(context.TODO(), logger, run, reportType, reportSubType, reportName, vcs, ucs, max)
+	output := ""
+	if passedOutput := parsedArgs["--output"]; passedOutput != nil {
+		output = passedOutput.(string)
+	}
+	format, err := es_utils.ParseOutputFormat(output)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	return es_utils.DisplayReport(context.TODO(), logger, run, reportType, reportSubType, reportName, vcs, ucs, max,
+		thresholds, format)
 }
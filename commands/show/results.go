@@ -4,40 +4,43 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
 
-	docopt "github.com/docopt/docopt-go"
 	"k8s.io/klog/v2/klogr"
 
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
 	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
 )
 
 // ResultHistory displays information about e2e sanity results.
 func ResultHistory(ctx context.Context, args []string) error {
 	doc := `Usage:
-	e2e_result show results [--vcs | --ucs] [--failed | --passed | --skipped] [--run=<id>] [--test=<name>] [--max=<int>]
+	e2e_result show results [--vcs | --ucs] [--failed | --passed | --skipped] [--serial | --parallel] [--run=<id>] [--test=<name>] [--maintainer=<name>] [--show-maintainer] [--details] [--exclude-quarantined] [--max=<int>] [--output=<format>] [--fail-on-failures]
 Options:
-  -h --help               Show this screen.
-     --vcs                Show e2e test results in vcs run.
-     --ucs                Show e2e test results in ucs run.
-     --failed             Show e2e test results filtering by failed tests.
-     --passed             Show e2e test results filtering by passed tests.
-     --skipped            Show e2e test results filtering by skipped tests.
-     --run=<id>           Show e2e test results in a specific (vcs or ucs) run 
-     --test=<name>        Show history for a specific test.
-     --max=<int>          Maximum number of results to display (default is 100)
+  -h --help                 Show this screen.
+     --vcs                  Show e2e test results in vcs run.
+     --ucs                  Show e2e test results in ucs run.
+     --failed               Show e2e test results filtering by failed tests.
+     --passed               Show e2e test results filtering by passed tests.
+     --skipped              Show e2e test results filtering by skipped tests.
+     --serial               Show only tests run in serial.
+     --parallel             Show only tests run in parallel.
+     --run=<id>             Show e2e test results in a specific (vcs or ucs) run 
+     --test=<name>          Show history for a specific test.
+     --maintainer=<name>    Show only tests maintained by name.
+     --show-maintainer      Display the MAINTAINER column.
+     --details              Display failure message, location and artifacts of tests.
+     --exclude-quarantined  Hide quarantined tests (they are marked otherwise).
+     --max=<int>            Maximum number of results to display (default is 100)
+     --output=<format>      Output format: table, json or csv (default is table)
+     --fail-on-failures     Exit with code 3 if any displayed, not quarantined, test failed.
 
 Description:
   The show results command shows information about e2e results.
 `
-	parsedArgs, err := docopt.ParseArgs(doc, nil, "1.0")
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
 	if err != nil {
-		fmt.Println(err)
-		return fmt.Errorf(
-			"invalid option: 'e2e_result %s'. Use flag '--help' to read about a specific subcommand. Error: %v",
-			strings.Join(args, " "),
-			err,
-		)
+		return err
 	}
 	if len(parsedArgs) == 0 {
 		return nil
@@ -45,30 +48,75 @@ Description:
 
 	logger := klogr.New()
 
-	vcs := parsedArgs["--vcs"].(bool)
-	ucs := parsedArgs["--ucs"].(bool)
+	filter := es_utils.ResultFilter{
+		Serial:   parsedArgs["--serial"].(bool),
+		Parallel: parsedArgs["--parallel"].(bool),
+	}
+
+	if parsedArgs["--vcs"].(bool) {
+		filter.Environment = "vcs"
+	} else if parsedArgs["--ucs"].(bool) {
+		filter.Environment = "ucs"
+	}
 
-	failed := parsedArgs["--failed"].(bool)
-	passed := parsedArgs["--passed"].(bool)
-	skipped := parsedArgs["--skipped"].(bool)
+	for _, result := range []string{"passed", "failed", "skipped"} {
+		if parsedArgs["--"+result].(bool) {
+			filter.Result = result
+		}
+	}
 
-	run := ""
 	if passedRun := parsedArgs["--run"]; passedRun != nil {
-		run = passedRun.(string)
+		filter.Run = passedRun.(string)
 	}
 
-	test := ""
 	if passedTest := parsedArgs["--test"]; passedTest != nil {
-		test = passedTest.(string)
+		filter.Test = passedTest.(string)
+	}
+
+	if passedMaintainer := parsedArgs["--maintainer"]; passedMaintainer != nil {
+		filter.Maintainer = passedMaintainer.(string)
+	}
+
+	q, err := quarantine.LoadActive(logger)
+	if err != nil {
+		return err
+	}
+
+	output := ""
+	if passedOutput := parsedArgs["--output"]; passedOutput != nil {
+		output = passedOutput.(string)
+	}
+	format, err := es_utils.ParseOutputFormat(output)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
 	}
 
-	max := 100
+	options := es_utils.ResultDisplayOptions{
+		ShowMaintainer:     parsedArgs["--show-maintainer"].(bool),
+		Quarantine:         q,
+		ExcludeQuarantined: parsedArgs["--exclude-quarantined"].(bool),
+		Details:            parsedArgs["--details"].(bool),
+		Format:             format,
+	}
+
+	filter.Max = 100
 	if passedMax := parsedArgs["--max"]; passedMax != nil {
-		max, err = strconv.Atoi(passedMax.(string))
+		filter.Max, err = strconv.Atoi(passedMax.(string))
 		if err != nil {
-			return err
+			return &cmdutil.UsageError{Args: args, Err: err}
 		}
 	}
 
-	return es_utils.DisplayResult(context.TODO(), logger, run, test, vcs, ucs, passed, failed, skipped, max)
+	failOnFailures := parsedArgs["--fail-on-failures"].(bool)
+
+	failures, err := es_utils.DisplayResult(context.TODO(), logger, filter, options)
+	if err != nil {
+		return err
+	}
+
+	if failOnFailures && failures > 0 {
+		return fmt.Errorf("%d failed test(s): %w", failures, cmdutil.ErrFailuresFound)
+	}
+
+	return nil
 }
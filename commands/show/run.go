@@ -0,0 +1,45 @@
+package show
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// RunInfo displays metadata of a run.
+func RunInfo(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show run <id> [--vcs | --ucs]
+Options:
+  -h --help               Show this screen.
+     --vcs                Show metadata of the vcs run.
+     --ucs                Show metadata of the ucs run.
+
+Description:
+  The show run command shows metadata (commit, branch, versions, CI job) pushed
+  for a run with 'push run-info'.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	run, err := strconv.Atoi(parsedArgs["<id>"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	vcs := parsedArgs["--vcs"].(bool)
+	ucs := parsedArgs["--ucs"].(bool)
+
+	return es_utils.DisplayRunInfo(ctx, logger, run, vcs, ucs)
+}
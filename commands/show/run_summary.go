@@ -0,0 +1,95 @@
+package show
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// RunSummary displays counts, durations, slowest tests, reports and top
+// resource consumers of a run.
+func RunSummary(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show run-summary --run=<id> [--env=<env>] [--top=<int>]
+Options:
+  -h --help               Show this screen.
+     --run=<id>           Run to summarize.
+     --env=<env>          Environment of the run, vcs or ucs (default is both).
+     --top=<int>          Number of slowest tests and top consumers to display (default is 5)
+
+Description:
+  The show run-summary command shows, in one screen, total/passed/failed/skipped
+  counts, total and wall-clock duration with a serial vs parallel breakdown, the
+  slowest tests, the reports and the top memory and CPU consumers of a run.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	run, err := strconv.Atoi(parsedArgs["--run"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	env := ""
+	if passedEnv := parsedArgs["--env"]; passedEnv != nil {
+		env = passedEnv.(string)
+	}
+	vcs, ucs, err := cmdutil.ParseEnvironment(env)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	top := 5
+	if passedTop := parsedArgs["--top"]; passedTop != nil {
+		top, err = strconv.Atoi(passedTop.(string))
+		if err != nil || top < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--top must be a positive integer")}
+		}
+	}
+
+	found := false
+	for _, e := range []string{"vcs", "ucs"} {
+		if (e == "vcs" && ucs) || (e == "ucs" && vcs) {
+			continue
+		}
+
+		data, err := es_utils.LoadRunData(ctx, logger, e, run)
+		if err != nil {
+			// When no environment is selected, the run may exist in only one of them.
+			if errors.Is(err, es_utils.ErrNoResults) && !vcs && !ucs {
+				continue
+			}
+			return err
+		}
+
+		info, _, err := es_utils.GetRunInfo(ctx, logger, e, run)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			fmt.Println()
+		}
+		found = true
+		es_utils.DisplayRunSummary(data, info, top)
+	}
+
+	if !found {
+		return fmt.Errorf("%w for run %d", es_utils.ErrNoResults, run)
+	}
+
+	return nil
+}
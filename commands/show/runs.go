@@ -2,13 +2,11 @@ package show
 
 import (
 	"context"
-	"fmt"
 	"strconv"
-	"strings"
 
-	docopt "github.com/docopt/docopt-go"
 	"k8s.io/klog/v2/klogr"
 
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
 	"github.com/gianlucam76/cs-e2e-result/es_utils"
 )
 
@@ -23,16 +21,12 @@ Options:
      --max=<int>          Maximum number of results to display (default is 100)
 
 Description:
-  The show runs command shows information about available runs for which results were collected.
+  The show runs command shows information about available runs for which results were collected,
+  with the metadata pushed with 'push run-info'.
 `
-	parsedArgs, err := docopt.ParseArgs(doc, nil, "1.0")
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
 	if err != nil {
-		fmt.Println(err)
-		return fmt.Errorf(
-			"invalid option: 'e2e_result %s'. Use flag '--help' to read about a specific subcommand. Error: %v",
-			strings.Join(args, " "),
-			err,
-		)
+		return err
 	}
 	if len(parsedArgs) == 0 {
 		return nil
@@ -47,7 +41,7 @@ Description:
 	if passedMax := parsedArgs["--max"]; passedMax != nil {
 		max, err = strconv.Atoi(passedMax.(string))
 		if err != nil {
-			return err
+			return &cmdutil.UsageError{Args: args, Err: err}
 		}
 	}
 
@@ -0,0 +1,108 @@
+package show
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	docopt "github.com/docopt/docopt-go"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/analysis"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Stats takes keyword then calls subcommand.
+func Stats(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show stats <command> [<args>...]
+
+    serial      show time spent in serial tests and serial tests which could run in parallel.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	See 'e2e_result show stats <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"show", "stats", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "serial":
+		return SerialStats(ctx, arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
+
+// SerialStats displays serial vs parallel statistics over the latest runs.
+func SerialStats(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show stats serial [--vcs | --ucs] [--runs=<int>] [--top=<int>]
+Options:
+  -h --help               Show this screen.
+     --vcs                Consider vcs runs only.
+     --ucs                Consider ucs runs only.
+     --runs=<int>         Number of latest runs to consider (default is 10)
+     --top=<int>          Number of longest serial tests to display (default is 10)
+
+Description:
+  The show stats serial command shows, for each run, how much of the wall time
+  is spent in serial tests, the longest serial tests and the serial tests whose
+  history suggests they could be run in parallel: tests which passed every time
+  they ran in parallel, or which never failed in at least 3 serial runs.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	vcs := parsedArgs["--vcs"].(bool)
+	ucs := parsedArgs["--ucs"].(bool)
+
+	runs := 10
+	if passedRuns := parsedArgs["--runs"]; passedRuns != nil {
+		runs, err = strconv.Atoi(passedRuns.(string))
+		if err != nil || runs < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--runs must be a positive integer")}
+		}
+	}
+
+	top := 10
+	if passedTop := parsedArgs["--top"]; passedTop != nil {
+		top, err = strconv.Atoi(passedTop.(string))
+		if err != nil || top < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--top must be a positive integer")}
+		}
+	}
+
+	stats, err := analysis.LoadSerialStats(ctx, logger, vcs, ucs, runs)
+	if err != nil {
+		return err
+	}
+
+	analysis.DisplaySerialStats(stats, top)
+
+	return nil
+}
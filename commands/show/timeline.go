@@ -0,0 +1,86 @@
+package show
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/analysis"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+)
+
+// Timeline displays when each test of a run was running and the critical path.
+func Timeline(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result show timeline --run=<id> --env=<env> [--format=<format>] [--out=<file>] [--width=<int>]
+Options:
+  -h --help               Show this screen.
+     --run=<id>           Run to display.
+     --env=<env>          Environment of the run (vcs or ucs).
+     --format=<format>    Output format: ascii, svg or html (default is ascii)
+     --out=<file>         Write the timeline to file instead of standard output.
+     --width=<int>        Number of columns of the ascii chart (default is 100)
+
+Description:
+  The show timeline command reconstructs, from start time and duration of each
+  test, a Gantt-style timeline of a run: overlapping parallel tests, serial tests
+  (marked with *), idle gaps where no test was running, and the critical path,
+  the chain of tests and idle gaps the wall-clock time of the run is made of.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	run, err := strconv.Atoi(parsedArgs["--run"].(string))
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	env := parsedArgs["--env"].(string)
+	if vcs, ucs, err := cmdutil.ParseEnvironment(env); err != nil || (!vcs && !ucs) {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--env must be vcs or ucs")}
+	}
+
+	format := analysis.TimelineASCII
+	if passedFormat := parsedArgs["--format"]; passedFormat != nil {
+		format, err = analysis.ParseTimelineFormat(passedFormat.(string))
+		if err != nil {
+			return &cmdutil.UsageError{Args: args, Err: err}
+		}
+	}
+
+	width := 100
+	if passedWidth := parsedArgs["--width"]; passedWidth != nil {
+		width, err = strconv.Atoi(passedWidth.(string))
+		if err != nil || width < 10 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--width must be an integer not lower than 10")}
+		}
+	}
+
+	timeline, err := analysis.LoadTimeline(ctx, logger, env, run)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if passedOut := parsedArgs["--out"]; passedOut != nil {
+		f, err := os.Create(passedOut.(string))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return analysis.WriteTimeline(w, timeline, format, width)
+}
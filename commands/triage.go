@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/commands/triage"
+)
+
+// Triage takes keyword then calls subcommand.
+func Triage(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result triage <command> [<args>...]
+
+    file-issues   open or update one issue per test failing in the latest consecutive runs.
+
+Options:
+	-h --help      Show this screen.
+
+Description:
+	See 'e2e_result triage <command> --help' to read about a specific subcommand.
+  `
+
+	parser := &docopt.Parser{
+		HelpHandler:   docopt.PrintHelpOnly,
+		OptionsFirst:  true,
+		SkipHelpFlags: false,
+	}
+
+	opts, err := parser.ParseArgs(doc, args, "1.0")
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	command := opts["<command>"].(string)
+	arguments := append([]string{"triage", command}, opts["<args>"].([]string)...)
+
+	switch command {
+	case "file-issues":
+		return triage.FileIssues(ctx, arguments)
+	default:
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q", command)}
+	}
+}
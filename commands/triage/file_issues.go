@@ -0,0 +1,122 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/issues"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+// FileIssues opens or updates one issue per persistently failing test.
+func FileIssues(ctx context.Context, args []string) error {
+	doc := `Usage:
+	e2e_result triage file-issues --repo=<owner/name> [--threshold=<int>] [--env=<env>] [--tracker=<name>] [--github-url=<url>] [--token-env=<var>] [--label=<name>] [--dry-run]
+Options:
+  -h --help               Show this screen.
+     --repo=<owner/name>  Repository issues are filed in.
+     --threshold=<int>    Number of latest consecutive runs a test must have failed in (default is 3)
+     --env=<env>          Environment to consider, vcs or ucs (default is both).
+     --tracker=<name>     Issue tracker (default is github, the only one supported).
+     --github-url=<url>   GitHub REST API URL (default is https://api.github.com)
+     --token-env=<var>    Environment variable containing the API token (default is GITHUB_TOKEN)
+     --label=<name>       Label identifying filed issues (default is e2e-persistent-failure)
+     --dry-run            Only report what would be done.
+
+Description:
+  The triage file-issues command detects tests which failed in each of the latest
+  consecutive runs and files one issue per test, listing failing runs, environments,
+  maintainer and test description. An open issue with the same title is updated
+  instead of opening a new one, so running the command again is idempotent.
+  Quarantined tests are ignored.
+`
+	parsedArgs, err := cmdutil.ParseArgs(doc, args)
+	if err != nil {
+		return err
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+
+	logger := klogr.New()
+
+	threshold := 3
+	if passedThreshold := parsedArgs["--threshold"]; passedThreshold != nil {
+		threshold, err = strconv.Atoi(passedThreshold.(string))
+		if err != nil || threshold < 1 {
+			return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("--threshold must be a positive integer")}
+		}
+	}
+
+	env := ""
+	if passedEnv := parsedArgs["--env"]; passedEnv != nil {
+		env = passedEnv.(string)
+	}
+	vcs, ucs, err := cmdutil.ParseEnvironment(env)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+	envs := make([]string, 0)
+	if !ucs {
+		envs = append(envs, "vcs")
+	}
+	if !vcs {
+		envs = append(envs, "ucs")
+	}
+
+	if passedTracker := parsedArgs["--tracker"]; passedTracker != nil && passedTracker.(string) != "github" {
+		return &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unsupported tracker %q", passedTracker)}
+	}
+
+	githubURL := issues.DefaultGitHubURL
+	if passedURL := parsedArgs["--github-url"]; passedURL != nil {
+		githubURL = passedURL.(string)
+	}
+	tokenEnv := "GITHUB_TOKEN"
+	if passedTokenEnv := parsedArgs["--token-env"]; passedTokenEnv != nil {
+		tokenEnv = passedTokenEnv.(string)
+	}
+	label := "e2e-persistent-failure"
+	if passedLabel := parsedArgs["--label"]; passedLabel != nil {
+		label = passedLabel.(string)
+	}
+
+	var tracker issues.IssueTracker
+	tracker, err = issues.NewGitHubTracker(githubURL, parsedArgs["--repo"].(string), os.Getenv(tokenEnv), label)
+	if err != nil {
+		return &cmdutil.UsageError{Args: args, Err: err}
+	}
+
+	q, err := quarantine.LoadActive(logger)
+	if err != nil {
+		return err
+	}
+
+	failures, err := issues.FindPersistentFailures(ctx, logger, envs, threshold, q.IsQuarantined)
+	if err != nil {
+		return err
+	}
+
+	outcomes, err := issues.FileIssues(ctx, tracker, failures, parsedArgs["--dry-run"].(bool))
+	displayOutcomes(outcomes)
+	return err
+}
+
+func displayOutcomes(outcomes []issues.Outcome) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"TEST", "ACTION", "ISSUE"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for _, o := range outcomes {
+		table.Append([]string{o.Test, string(o.Action), o.URL})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,262 @@
+package es_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// IndexDuplicates reports the duplicated documents of an index, i.e.
+// documents sharing the same id as returned by DocumentID, and the documents
+// stored with another id.
+type IndexDuplicates struct {
+	// URL is the Elasticsearch URL index is stored at
+	URL string
+	// Index is the index name
+	Index string
+	// Documents is the number of documents in the index
+	Documents int
+	// Duplicated is the number of documents stored more than once
+	Duplicated int
+	// Moves are the kept copies, of duplicated documents or not, stored with
+	// an id other than the one returned by DocumentID
+	Moves []DocumentMove
+	// Extra are the ids of the copies to delete: one copy of each duplicated
+	// document is kept
+	Extra []string
+	// Moved is the number of documents stored again with their new id
+	Moved int
+	// Deleted is the number of copies deleted
+	Deleted int
+}
+
+// DocumentMove is a document to store again with the id returned by
+// DocumentID, so that pushing it again replaces it.
+type DocumentMove struct {
+	// ID is the id document is stored with
+	ID string
+	// NewID is the id returned by DocumentID
+	NewID string
+}
+
+// storedDocument is a copy of a document found by FindDuplicates.
+type storedDocument struct {
+	id   string
+	time time.Time
+}
+
+// FindDuplicates scans all indices and returns, for each index, the copies of
+// duplicated documents to delete and the documents to move. The copy kept is
+// the one stored with the id returned by DocumentID, if any, the most recent
+// one otherwise, which is then moved to that id. Documents without
+// environment or run are ignored. Nothing is changed. Indices which do not
+// exist are skipped.
+func FindDuplicates(ctx context.Context, logger logr.Logger) ([]IndexDuplicates, error) {
+	duplicates := make([]IndexDuplicates, 0)
+	for i := range managedIndices {
+		m := &managedIndices[i]
+		c, err := GetClient(m.esURL())
+		if err != nil {
+			logger.Error(err, "Failed to get client")
+			return nil, err
+		}
+		if err := VerifyIndex(ctx, logger, c, m.esURL(), m.index); err != nil {
+			var notFound *IndexNotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			}
+			logger.Error(err, "Failed to verify index")
+			return nil, err
+		}
+
+		d := IndexDuplicates{URL: m.esURL(), Index: m.index}
+		copies := make(map[string][]storedDocument)
+		err = scrollDocuments(ctx, c, m, elastic.NewMatchAllQuery(), func(hit *elastic.SearchHit) error {
+			d.Documents++
+			key, err := DocumentID(m.kind, hit.Source)
+			if err != nil {
+				return nil
+			}
+			copies[key] = append(copies[key], storedDocument{id: hit.Id, time: documentTime(hit.Source, m.timeField)})
+			return nil
+		})
+		if err != nil {
+			logger.Error(err, "Failed to scroll documents", "index", m.index)
+			return nil, err
+		}
+
+		for key, docs := range copies {
+			if len(docs) > 1 {
+				d.Duplicated++
+			}
+			sort.Slice(docs, func(i, j int) bool {
+				if (docs[i].id == key) != (docs[j].id == key) {
+					return docs[i].id == key
+				}
+				if !docs[i].time.Equal(docs[j].time) {
+					return docs[i].time.After(docs[j].time)
+				}
+				return docs[i].id < docs[j].id
+			})
+			if docs[0].id != key {
+				d.Moves = append(d.Moves, DocumentMove{ID: docs[0].id, NewID: key})
+			}
+			for _, doc := range docs[1:] {
+				d.Extra = append(d.Extra, doc.id)
+			}
+		}
+		sort.Slice(d.Moves, func(i, j int) bool { return d.Moves[i].ID < d.Moves[j].ID })
+		sort.Strings(d.Extra)
+
+		duplicates = append(duplicates, d)
+	}
+
+	return duplicates, nil
+}
+
+// RemoveDuplicates moves the documents and deletes the copies found by
+// FindDuplicates, and records the number of documents moved and of copies
+// deleted. A document is stored with its new id before its former id is
+// deleted: if interrupted, running FindDuplicates and RemoveDuplicates again
+// completes the work.
+func RemoveDuplicates(ctx context.Context, logger logr.Logger, duplicates []IndexDuplicates) error {
+	for i := range duplicates {
+		d := &duplicates[i]
+		if len(d.Moves) == 0 && len(d.Extra) == 0 {
+			continue
+		}
+
+		c, err := GetClient(d.URL)
+		if err != nil {
+			logger.Error(err, "Failed to get client")
+			return err
+		}
+
+		for start := 0; start < len(d.Moves); start += bulkSize {
+			end := start + bulkSize
+			if end > len(d.Moves) {
+				end = len(d.Moves)
+			}
+
+			moved, err := moveDocuments(ctx, c, d.URL, d.Index, d.Moves[start:end])
+			if err != nil {
+				logger.Error(err, "Failed to move documents", "index", d.Index)
+				return err
+			}
+			d.Moved += moved
+		}
+
+		for start := 0; start < len(d.Extra); start += bulkSize {
+			end := start + bulkSize
+			if end > len(d.Extra) {
+				end = len(d.Extra)
+			}
+
+			bulk := c.Bulk().Index(d.Index).Refresh("true")
+			for _, id := range d.Extra[start:end] {
+				bulk.Add(elastic.NewBulkDeleteRequest().Id(id))
+			}
+
+			if err := runBulk(ctx, d.URL, d.Index, bulk); err != nil {
+				logger.Error(err, "Failed to delete duplicated documents", "index", d.Index)
+				return err
+			}
+			d.Deleted += end - start
+		}
+	}
+
+	return nil
+}
+
+// moveDocuments stores the documents of moves with their new id, then deletes
+// their former id. Documents deleted meanwhile are skipped. It returns the
+// number of documents moved.
+func moveDocuments(ctx context.Context, c *elastic.Client, esURL, index string, moves []DocumentMove) (int, error) {
+	mget := c.MultiGet()
+	for _, m := range moves {
+		mget.Add(elastic.NewMultiGetItem().Index(index).Id(m.ID))
+	}
+	var response *elastic.MgetResponse
+	err := runRequest(ctx, esURL, index, func() error {
+		var err error
+		response, err = mget.Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	sources := make(map[string]json.RawMessage, len(response.Docs))
+	for _, doc := range response.Docs {
+		if doc.Found {
+			sources[doc.Id] = doc.Source
+		}
+	}
+
+	store := c.Bulk().Index(index).Refresh("true")
+	remove := c.Bulk().Index(index).Refresh("true")
+	for _, m := range moves {
+		if source, ok := sources[m.ID]; ok {
+			store.Add(elastic.NewBulkIndexRequest().Id(m.NewID).Doc(source))
+			remove.Add(elastic.NewBulkDeleteRequest().Id(m.ID))
+		}
+	}
+	moved := store.NumberOfActions()
+	if moved == 0 {
+		return 0, nil
+	}
+
+	if err := runBulk(ctx, esURL, index, store); err != nil {
+		return 0, err
+	}
+	if err := runBulk(ctx, esURL, index, remove); err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+// documentTime returns the time of a document, zero if unset.
+func documentTime(source json.RawMessage, timeField string) time.Time {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(source, &fields); err != nil {
+		return time.Time{}
+	}
+	var t time.Time
+	if err := json.Unmarshal(fields[timeField], &t); err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// DisplayDuplicates displays, for each index, the number of duplicated
+// documents, of documents to move and of copies to delete.
+func DisplayDuplicates(duplicates []IndexDuplicates, deleted bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"INDEX", "DOCUMENTS", "DUPLICATED", "TO MOVE", "EXTRA COPIES"}
+	if deleted {
+		header = append(header, "MOVED", "DELETED")
+	}
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for i := range duplicates {
+		d := &duplicates[i]
+		row := []string{d.Index, strconv.Itoa(d.Documents), strconv.Itoa(d.Duplicated),
+			strconv.Itoa(len(d.Moves)), strconv.Itoa(len(d.Extra))}
+		if deleted {
+			row = append(row, strconv.Itoa(d.Moved), strconv.Itoa(d.Deleted))
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+}
@@ -0,0 +1,230 @@
+package es_utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// fakeES is an in-memory Elasticsearch 7 holding the documents of some
+// indices. It answers scrolls with all documents of an index, multi gets and
+// bulk index and delete actions.
+type fakeES struct {
+	mu   sync.Mutex
+	docs map[string]map[string]json.RawMessage
+}
+
+func (es *fakeES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	index := parts[0]
+	docs, exists := es.docs[index]
+
+	switch {
+	case index == "":
+		_, _ = w.Write([]byte(`{"version":{"number":"7.17.9"},"tagline":"You Know, for Search"}`))
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(parts) == 2 && parts[1] == "_mapping":
+		fmt.Fprintf(w, `{%q:{"mappings":{"_meta":{%q:%d},"properties":{}}}}`, index, schemaVersionKey, SchemaVersion)
+	case len(parts) == 2 && parts[1] == "_search":
+		hits := make([]map[string]interface{}, 0, len(docs))
+		for id, source := range docs {
+			hits = append(hits, map[string]interface{}{"_index": index, "_id": id, "_source": source})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"_scroll_id": "scroll-" + index,
+			"hits": map[string]interface{}{"total": map[string]interface{}{"value": len(hits)}, "hits": hits}})
+	case index == "_search" && len(parts) == 2 && parts[1] == "scroll":
+		// The first page holds all documents.
+		_, _ = w.Write([]byte(`{"_scroll_id":"done","hits":{"total":{"value":0},"hits":[]}}`))
+	case index == "_mget":
+		es.mget(w, r)
+	case index == "_bulk" || len(parts) == 2 && parts[1] == "_bulk":
+		es.bulk(w, r, index)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (es *fakeES) mget(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Docs []struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		} `json:"docs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	docs := make([]map[string]interface{}, 0, len(request.Docs))
+	for _, d := range request.Docs {
+		source, found := es.docs[d.Index][d.ID]
+		doc := map[string]interface{}{"_index": d.Index, "_id": d.ID, "found": found}
+		if found {
+			doc["_source"] = source
+		}
+		docs = append(docs, doc)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs})
+}
+
+func (es *fakeES) bulk(w http.ResponseWriter, r *http.Request, index string) {
+	items := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for op, meta := range action {
+			if meta.Index == "" {
+				meta.Index = index
+			}
+			if es.docs[meta.Index] == nil {
+				es.docs[meta.Index] = make(map[string]json.RawMessage)
+			}
+			switch op {
+			case "index":
+				if !scanner.Scan() {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				es.docs[meta.Index][meta.ID] = append(json.RawMessage(nil), scanner.Bytes()...)
+			case "delete":
+				delete(es.docs[meta.Index], meta.ID)
+			}
+			items = append(items, map[string]interface{}{op: map[string]interface{}{
+				"_index": meta.Index, "_id": meta.ID, "status": http.StatusOK}})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"took": 1, "errors": false, "items": items})
+}
+
+// ids returns the ids of the documents of index.
+func (es *fakeES) ids(index string) map[string]bool {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	ids := make(map[string]bool)
+	for id := range es.docs[index] {
+		ids[id] = true
+	}
+	return ids
+}
+
+// newFakeES starts es and configures the store to use it.
+func newFakeES(t *testing.T, es *fakeES) {
+	t.Helper()
+	server := httptest.NewServer(es)
+	SetRetryPolicy(RetryPolicy{})
+	if err := SetStore(&StoreConfig{URL: server.URL}); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = SetStore(&StoreConfig{})
+		SetRetryPolicy(DefaultRetryPolicy)
+		server.Close()
+	})
+}
+
+func TestDedupe(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	results := []Result{
+		{Name: "upgrade", Result: "passed", Environment: "vcs", Run: 2927, StartTime: start},
+		{Name: "install", Result: "passed", Environment: "vcs", Run: 2927, StartTime: start.Add(time.Minute)},
+		{Name: "backup", Result: "failed", Environment: "vcs", Run: 2927, StartTime: start.Add(2 * time.Minute)},
+	}
+	source := func(r Result) json.RawMessage {
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("failed to marshal result: %v", err)
+		}
+		return b
+	}
+	olderInstall := results[1]
+	olderInstall.StartTime = start
+
+	// upgrade is stored once with a random id, install twice with random
+	// ids and backup with its id and a random one.
+	es := &fakeES{docs: map[string]map[string]json.RawMessage{
+		resultCloudstackIndex: {
+			"legacy-1":            source(results[0]),
+			"legacy-2":            source(olderInstall),
+			"legacy-3":            source(results[1]),
+			ResultID(&results[2]): source(results[2]),
+			"legacy-4":            source(results[2]),
+		},
+	}}
+	newFakeES(t, es)
+
+	duplicates, err := FindDuplicates(ctx, logr.Discard())
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	want := []IndexDuplicates{{
+		URL: storeURL(resultCloudstackESURL), Index: resultCloudstackIndex, Documents: 5, Duplicated: 2,
+		Moves: []DocumentMove{{ID: "legacy-1", NewID: ResultID(&results[0])}, {ID: "legacy-3", NewID: ResultID(&results[1])}},
+		Extra: []string{"legacy-2", "legacy-4"},
+	}}
+	if !reflect.DeepEqual(duplicates, want) {
+		t.Fatalf("duplicates are %+v, want %+v", duplicates, want)
+	}
+
+	if err := RemoveDuplicates(ctx, logr.Discard(), duplicates); err != nil {
+		t.Fatalf("RemoveDuplicates failed: %v", err)
+	}
+	if duplicates[0].Moved != 2 || duplicates[0].Deleted != 2 {
+		t.Errorf("moved %d and deleted %d documents, want 2 and 2", duplicates[0].Moved, duplicates[0].Deleted)
+	}
+
+	wantIDs := map[string]bool{ResultID(&results[0]): true, ResultID(&results[1]): true, ResultID(&results[2]): true}
+	if ids := es.ids(resultCloudstackIndex); !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("after dedupe, ids are %v, want %v", ids, wantIDs)
+	}
+	es.mu.Lock()
+	if got := es.docs[resultCloudstackIndex][ResultID(&results[1])]; !bytes.Equal(got, source(results[1])) {
+		t.Errorf("kept install copy is %s, want the most recent one", got)
+	}
+	es.mu.Unlock()
+
+	// Pushing the results again replaces them.
+	if err := PushResults(ctx, logr.Discard(), results); err != nil {
+		t.Fatalf("PushResults failed: %v", err)
+	}
+	if ids := es.ids(resultCloudstackIndex); !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("after push, ids are %v, want %v", ids, wantIDs)
+	}
+
+	duplicates, err = FindDuplicates(ctx, logr.Discard())
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(duplicates) != 1 || len(duplicates[0].Moves) != 0 || len(duplicates[0].Extra) != 0 {
+		t.Errorf("duplicates after push are %+v, want none", duplicates)
+	}
+}
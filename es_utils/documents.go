@@ -0,0 +1,274 @@
+package es_utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-logr/logr"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// Kinds of documents stored by e2e_result, one per index.
+const (
+	// ResultDocuments are test results (Result)
+	ResultDocuments = "results"
+	// ReportDocuments are duration reports (Report)
+	ReportDocuments = "reports"
+	// UsageDocuments are resource usage reports (UsageReport)
+	UsageDocuments = "usage"
+	// RunInfoDocuments are run metadata (RunInfo)
+	RunInfoDocuments = "runs"
+)
+
+// scrollSize is the number of documents fetched by each scroll request.
+const scrollSize = 1000
+
+// DocumentKinds returns the kinds of documents stored by e2e_result.
+func DocumentKinds() []string {
+	kinds := make([]string, len(managedIndices))
+	for i := range managedIndices {
+		kinds[i] = managedIndices[i].kind
+	}
+	return kinds
+}
+
+func indexOfKind(kind string) (*managedIndex, error) {
+	for i := range managedIndices {
+		if managedIndices[i].kind == kind {
+			return &managedIndices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown kind of documents %q", kind)
+}
+
+// DocumentSelection selects documents by environment and run. Runs are
+// selected as a whole, from all indices.
+type DocumentSelection struct {
+	// Environments are the environments whose runs are selected
+	Environments []string
+	// Since, if set, selects the runs since the oldest run with a document
+	// produced at or after Since
+	Since time.Time
+	// Run, if positive, selects only this run
+	Run int
+}
+
+// ScanDocuments calls fn with the source of every document of kind selected
+// by selection. Documents are scrolled, so there is no limit on their number.
+func ScanDocuments(ctx context.Context, logger logr.Logger,
+	kind string, selection DocumentSelection,
+	fn func(source json.RawMessage) error,
+) error {
+	m, err := indexOfKind(kind)
+	if err != nil {
+		return err
+	}
+
+	c, err := GetClient(m.esURL())
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return err
+	}
+
+	if err = VerifyIndex(ctx, logger, c, m.esURL(), m.index); err != nil {
+		var notFound *IndexNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		logger.Error(err, "Failed to verify index")
+		return err
+	}
+
+	for _, env := range selection.Environments {
+		query := elastic.NewBoolQuery().Filter(elastic.NewMatchQuery("environment", env))
+		switch {
+		case selection.Run > 0:
+			query.Filter(elastic.NewTermQuery("run", selection.Run))
+		case !selection.Since.IsZero():
+			firstRun, err := firstRunSince(ctx, logger, env, selection.Since)
+			if err != nil {
+				return err
+			}
+			if firstRun == 0 {
+				continue
+			}
+			query.Filter(elastic.NewRangeQuery("run").Gte(firstRun))
+		}
+
+		err := scrollDocuments(ctx, c, m, query, func(hit *elastic.SearchHit) error {
+			return fn(hit.Source)
+		})
+		if err != nil {
+			logger.Error(err, "Failed to scroll documents")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scrollDocuments calls fn with every document of index m matching query.
+func scrollDocuments(ctx context.Context, c *elastic.Client, m *managedIndex,
+	query elastic.Query, fn func(hit *elastic.SearchHit) error,
+) error {
+	scroll := c.Scroll(m.index).Query(query).Size(scrollSize).Sort("_doc", true)
+	defer func() { _ = scroll.Clear(ctx) }()
+
+	for {
+		var page *elastic.SearchResult
+		done := false
+		err := runRequest(ctx, m.esURL(), m.index, func() error {
+			var err error
+			page, err = scroll.Do(ctx)
+			if err == io.EOF {
+				done = true
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		for _, hit := range page.Hits.Hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// firstRunSince returns the oldest run of env with a document, of any kind,
+// produced at or after since. Zero if none.
+func firstRunSince(ctx context.Context, logger logr.Logger, env string, since time.Time) (int, error) {
+	firstRun := 0
+	for i := range managedIndices {
+		run, err := boundaryRun(ctx, logger, &managedIndices[i], env, since, time.Time{}, true)
+		if err != nil {
+			return 0, err
+		}
+		if run > 0 && (firstRun == 0 || run < firstRun) {
+			firstRun = run
+		}
+	}
+	return firstRun, nil
+}
+
+// StoreDocuments stores documents of kind, with the id returned by
+// DocumentID: storing a document again replaces it.
+func StoreDocuments(ctx context.Context, logger logr.Logger, kind string, sources []json.RawMessage) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	m, err := indexOfKind(kind)
+	if err != nil {
+		return err
+	}
+
+	c, err := GetClient(m.esURL())
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return err
+	}
+
+	if err = ensureIndex(ctx, c, m.esURL(), m.index); err != nil {
+		logger.Error(err, "Failed to create index")
+		return err
+	}
+
+	for start := 0; start < len(sources); start += bulkSize {
+		end := start + bulkSize
+		if end > len(sources) {
+			end = len(sources)
+		}
+
+		bulk := c.Bulk().Index(m.index)
+		for i := start; i < end; i++ {
+			id, err := DocumentID(kind, sources[i])
+			if err != nil {
+				return err
+			}
+			bulk.Add(elastic.NewBulkIndexRequest().Id(id).Doc(sources[i]))
+		}
+
+		if err := runBulk(ctx, m.esURL(), m.index, bulk); err != nil {
+			logger.Error(err, "Failed to store documents")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DocumentID returns the id of a document of kind. The id is derived from
+// the fields identifying the document, so that storing the same document
+// twice does not duplicate it:
+//   - results: environment, run and test name;
+//   - reports: environment, run, type, subtype and name;
+//   - usage reports: environment, run and pod name;
+//   - run metadata: environment and run.
+func DocumentID(kind string, source json.RawMessage) (string, error) {
+	var key struct {
+		Environment string `json:"environment"`
+		Run         int    `json:"run"`
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		SubType     string `json:"subType"`
+	}
+	if err := json.Unmarshal(source, &key); err != nil {
+		return "", fmt.Errorf("invalid %s document: %w", kind, err)
+	}
+	if key.Environment == "" || key.Run == 0 {
+		return "", fmt.Errorf("invalid %s document: environment and run are required", kind)
+	}
+
+	switch kind {
+	case ResultDocuments:
+		return ResultID(&Result{Environment: key.Environment, Run: key.Run, Name: key.Name}), nil
+	case ReportDocuments:
+		return ReportID(&Report{Environment: key.Environment, Run: key.Run,
+			Type: key.Type, SubType: key.SubType, Name: key.Name}), nil
+	case UsageDocuments:
+		return UsageReportID(&UsageReport{Environment: key.Environment, Run: key.Run, Name: key.Name}), nil
+	case RunInfoDocuments:
+		return runInfoID(key.Environment, key.Run), nil
+	default:
+		return "", fmt.Errorf("unknown kind of documents %q", kind)
+	}
+}
+
+// ResultID returns the document id of r, derived from its environment, run
+// and test name.
+func ResultID(r *Result) string {
+	return documentID(r.Environment, r.Run, r.Name)
+}
+
+// ReportID returns the document id of r, derived from its environment, run,
+// type, subtype and name.
+func ReportID(r *Report) string {
+	return documentID(r.Environment, r.Run, r.Type, r.SubType, r.Name)
+}
+
+// UsageReportID returns the document id of r, derived from its environment,
+// run and pod name.
+func UsageReportID(r *UsageReport) string {
+	return documentID(r.Environment, r.Run, r.Name)
+}
+
+// documentID returns "<env>-<run>-<hash of fields>".
+func documentID(env string, run int, fields ...string) string {
+	h := sha256.New()
+	for _, f := range fields {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s-%d-%x", env, run, h.Sum(nil)[:8])
+}
@@ -0,0 +1,175 @@
+package es_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/go-logr/logr"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// documentStore runs the searches of GetResults, GetReports, GetUsageReports
+// and GetAvailableRuns, and indexes the documents of PushResults, with the
+// client of the configured backend. Requests and responses are the ones of
+// that backend: the Elasticsearch 7 client is not involved for Elasticsearch
+// 8 and OpenSearch.
+// Errors are returned as ConnectionError or QueryError.
+type documentStore interface {
+	// verifyIndex returns an IndexNotFoundError if index does not exist and
+	// a SchemaVersionError if index was created by a more recent version of
+	// e2e_result
+	verifyIndex(ctx context.Context, logger logr.Logger, index string) error
+	// ensureIndex creates index, with its mapping, if it does not exist
+	ensureIndex(ctx context.Context, index string) error
+	// search returns at most size documents of index selected by all
+	// filters, most recent run first
+	search(ctx context.Context, index string, filters []searchFilter, size int) (*searchResponse, error)
+	// runs returns at most size runs of environment env with a document in
+	// index, most recent first
+	runs(ctx context.Context, index, env string, size int) ([]int, error)
+	// index stores docs in index, replacing the documents with the same id
+	index(ctx context.Context, index string, docs []document) error
+}
+
+// searchFilter selects the documents whose field matches value, as analyzed
+// by Elasticsearch (match query) unless exact is set (term query).
+type searchFilter struct {
+	field string
+	value interface{}
+	exact bool
+}
+
+// searchResponse is the response to a search of a documentStore.
+type searchResponse struct {
+	// took is the time the search took in milliseconds
+	took int64
+	// sources are the sources of the documents found
+	sources []json.RawMessage
+}
+
+// document is a document to index.
+type document struct {
+	id     string
+	source interface{}
+}
+
+// errNoRunAggregation is returned when a search response lacks the run
+// aggregation.
+var errNoRunAggregation = errors.New("failed to get term aggregation results")
+
+// stores caches the documentStore of each Elasticsearch URL.
+var stores sync.Map
+
+// getStore returns the documentStore of esURL for the configured backend. It
+// is created on first use and shared afterwards.
+func getStore(esURL string) (documentStore, error) {
+	if s, ok := stores.Load(esURL); ok {
+		return s.(documentStore), nil
+	}
+
+	var s documentStore
+	switch store.Backend {
+	case Elasticsearch8, OpenSearch:
+		p, err := getPerformer(esURL)
+		if err != nil {
+			return nil, err
+		}
+		if store.Backend == Elasticsearch8 {
+			s = &dslStore{esURL: esURL, client: es8Client{transport: p}}
+		} else {
+			s = &dslStore{esURL: esURL, client: openSearchClient{transport: p}}
+		}
+	default:
+		c, err := GetClient(esURL)
+		if err != nil {
+			return nil, err
+		}
+		s = &es7Store{esURL: esURL, c: c}
+	}
+
+	actual, _ := stores.LoadOrStore(esURL, s)
+	return actual.(documentStore), nil
+}
+
+// es7Store is the documentStore of Elasticsearch 7.
+type es7Store struct {
+	esURL string
+	c     *elastic.Client
+}
+
+func (s *es7Store) verifyIndex(ctx context.Context, logger logr.Logger, index string) error {
+	return VerifyIndex(ctx, logger, s.c, s.esURL, index)
+}
+
+func (s *es7Store) ensureIndex(ctx context.Context, index string) error {
+	return ensureIndex(ctx, s.c, s.esURL, index)
+}
+
+func (s *es7Store) search(ctx context.Context, index string, filters []searchFilter, size int,
+) (*searchResponse, error) {
+	generalQ := elastic.NewBoolQuery().Should()
+	for _, f := range filters {
+		if f.exact {
+			generalQ.Filter(elastic.NewTermQuery(f.field, f.value))
+		} else {
+			generalQ.Filter(elastic.NewMatchQuery(f.field, f.value))
+		}
+	}
+
+	searchResult, err := runQuery(ctx, s.esURL, index, func() (*elastic.SearchResult, error) {
+		return s.c.Search().Index(index).Query(generalQ).Size(size).
+			SortBy(elastic.NewFieldSort("run").Desc().SortMode("max")).
+			Pretty(true). // pretty print request and response JSON
+			Do(ctx)       // execute
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &searchResponse{took: searchResult.TookInMillis}
+	if searchResult.Hits != nil {
+		for _, hit := range searchResult.Hits.Hits {
+			response.sources = append(response.sources, hit.Source)
+		}
+	}
+	return response, nil
+}
+
+func (s *es7Store) runs(ctx context.Context, index, env string, size int) ([]int, error) {
+	field := "run"
+	termAggr := elastic.NewTermsAggregation().Field(field).Size(size).Order("_key", false)
+	searchResult, err := runQuery(ctx, s.esURL, index, func() (*elastic.SearchResult, error) {
+		return s.c.Search().Index(index).
+			Query(elastic.NewMatchQuery("environment", env)).
+			Aggregation(field, termAggr).
+			Do(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, found := searchResult.Aggregations.Terms(field)
+	if !found {
+		return nil, &QueryError{URL: s.esURL, Index: index, Err: errNoRunAggregation}
+	}
+
+	runs := make([]int, 0, len(b.Buckets))
+	for _, bucket := range b.Buckets {
+		id, err := bucket.KeyNumber.Int64()
+		if err != nil {
+			return nil, &QueryError{URL: s.esURL, Index: index, Err: err}
+		}
+		runs = append(runs, int(id))
+	}
+	return runs, nil
+}
+
+func (s *es7Store) index(ctx context.Context, index string, docs []document) error {
+	bulk := s.c.Bulk().Index(index)
+	for i := range docs {
+		bulk.Add(elastic.NewBulkIndexRequest().Id(docs[i].id).Doc(docs[i].source))
+	}
+	return runBulk(ctx, s.esURL, index, bulk)
+}
@@ -0,0 +1,406 @@
+package es_utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/go-logr/logr"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+// dslStore is the documentStore of Elasticsearch 8 and OpenSearch. Requests
+// are written in the query DSL the two share, and sent by the official
+// client of the backend.
+type dslStore struct {
+	esURL  string
+	client dslClient
+}
+
+// dslClient sends the requests of a dslStore with the API of the client of a
+// backend. Responses are returned whatever their status.
+type dslClient interface {
+	indexExists(ctx context.Context, index string) (*rawResponse, error)
+	getMapping(ctx context.Context, index string) (*rawResponse, error)
+	createIndex(ctx context.Context, index string, body []byte) (*rawResponse, error)
+	search(ctx context.Context, index string, body []byte) (*rawResponse, error)
+	bulk(ctx context.Context, index string, body []byte) (*rawResponse, error)
+}
+
+// rawResponse is the status and body of a response.
+type rawResponse struct {
+	status int
+	body   []byte
+}
+
+// responseError is an error response of Elasticsearch 8 or OpenSearch.
+type responseError struct {
+	status int
+	// errType and reason describe the error, if the response does
+	errType string
+	reason  string
+}
+
+func (e *responseError) Error() string {
+	if e.reason == "" {
+		return fmt.Sprintf("%d %s", e.status, http.StatusText(e.status))
+	}
+	return fmt.Sprintf("%d %s: %s [type=%s]", e.status, http.StatusText(e.status), e.reason, e.errType)
+}
+
+// newResponseError returns the error reported by res.
+func newResponseError(res *rawResponse) *responseError {
+	e := &responseError{status: res.status}
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(res.body, &body) != nil || body.Error == nil {
+		return e
+	}
+	var details struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(body.Error, &details) == nil {
+		e.errType, e.reason = details.Type, details.Reason
+	} else {
+		_ = json.Unmarshal(body.Error, &e.reason)
+	}
+	return e
+}
+
+// isNotFound returns true if err is a 404 response.
+func isNotFound(err error) bool {
+	var e *responseError
+	return errors.As(err, &e) && e.status == http.StatusNotFound
+}
+
+// send sends a request, retrying transient failures, and returns its
+// response. Error responses are returned as a QueryError or ConnectionError
+// wrapping a responseError.
+func (s *dslStore) send(ctx context.Context, index string,
+	request func() (*rawResponse, error),
+) (*rawResponse, error) {
+	var res *rawResponse
+	err := runRequest(ctx, s.esURL, index, func() error {
+		var err error
+		res, err = request()
+		if err == nil && res.status >= http.StatusMultipleChoices {
+			return newResponseError(res)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *dslStore) verifyIndex(ctx context.Context, logger logr.Logger, index string) error {
+	_, err := s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.indexExists(ctx, index)
+	})
+	if isNotFound(err) {
+		return &IndexNotFoundError{URL: s.esURL, Index: index}
+	}
+	if err != nil {
+		return err
+	}
+
+	return checkMappingVersion(logger, s.esURL, index, func() (map[string]interface{}, error) {
+		return s.getMapping(ctx, index)
+	})
+}
+
+// getMapping returns the mapping of index.
+func (s *dslStore) getMapping(ctx context.Context, index string) (map[string]interface{}, error) {
+	res, err := s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.getMapping(ctx, index)
+	})
+	if isNotFound(err) {
+		return nil, &IndexNotFoundError{URL: s.esURL, Index: index}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Response is {"<index>": {"mappings": {"_meta": {...}, "properties": {...}}}}
+	var response map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.Unmarshal(res.body, &response); err != nil {
+		return nil, &QueryError{URL: s.esURL, Index: index, Err: err}
+	}
+	return response[index].Mappings, nil
+}
+
+func (s *dslStore) ensureIndex(ctx context.Context, index string) error {
+	_, err := s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.indexExists(ctx, index)
+	})
+	if err == nil || !isNotFound(err) {
+		return err
+	}
+
+	m := managedIndexNamed(index)
+	if m == nil {
+		return nil
+	}
+	body, err := json.Marshal(map[string]interface{}{"mappings": m.mapping()})
+	if err != nil {
+		return err
+	}
+	_, err = s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.createIndex(ctx, index, body)
+	})
+	// Index might have been created concurrently.
+	var e *responseError
+	if errors.As(err, &e) && e.errType == "resource_already_exists_exception" {
+		return nil
+	}
+	return err
+}
+
+// searchBody returns the query DSL of a documentStore search.
+func searchBody(filters []searchFilter, size int) map[string]interface{} {
+	clauses := make([]interface{}, len(filters))
+	for i, f := range filters {
+		if f.exact {
+			clauses[i] = map[string]interface{}{"term": map[string]interface{}{f.field: f.value}}
+		} else {
+			clauses[i] = map[string]interface{}{"match": map[string]interface{}{
+				f.field: map[string]interface{}{"query": f.value}}}
+		}
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": clauses}},
+		"size":  size,
+		"sort":  []interface{}{map[string]interface{}{"run": map[string]interface{}{"order": "desc", "mode": "max"}}},
+	}
+}
+
+// runsBody returns the query DSL of a documentStore runs search.
+func runsBody(env string, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"match": map[string]interface{}{"environment": map[string]interface{}{"query": env}}},
+		"aggs": map[string]interface{}{"run": map[string]interface{}{"terms": map[string]interface{}{
+			"field": "run", "size": size, "order": map[string]interface{}{"_key": "desc"}}}},
+	}
+}
+
+// dslSearchResponse is a search response of Elasticsearch 8 and OpenSearch.
+type dslSearchResponse struct {
+	Took int64 `json:"took"`
+	Hits struct {
+		Hits []struct {
+			Source json.RawMessage `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Run *struct {
+			Buckets []struct {
+				Key json.Number `json:"key"`
+			} `json:"buckets"`
+		} `json:"run"`
+	} `json:"aggregations"`
+}
+
+// searchDSL runs the search described by query.
+func (s *dslStore) searchDSL(ctx context.Context, index string, query map[string]interface{}) (*dslSearchResponse, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.search(ctx, index, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dslSearchResponse{}
+	if err := json.Unmarshal(res.body, response); err != nil {
+		return nil, &QueryError{URL: s.esURL, Index: index, Err: err}
+	}
+	return response, nil
+}
+
+func (s *dslStore) search(ctx context.Context, index string, filters []searchFilter, size int,
+) (*searchResponse, error) {
+	dsl, err := s.searchDSL(ctx, index, searchBody(filters, size))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &searchResponse{took: dsl.Took}
+	for _, hit := range dsl.Hits.Hits {
+		response.sources = append(response.sources, hit.Source)
+	}
+	return response, nil
+}
+
+func (s *dslStore) runs(ctx context.Context, index, env string, size int) ([]int, error) {
+	dsl, err := s.searchDSL(ctx, index, runsBody(env, size))
+	if err != nil {
+		return nil, err
+	}
+	if dsl.Aggregations.Run == nil {
+		return nil, &QueryError{URL: s.esURL, Index: index, Err: errNoRunAggregation}
+	}
+
+	runs := make([]int, 0, len(dsl.Aggregations.Run.Buckets))
+	for _, bucket := range dsl.Aggregations.Run.Buckets {
+		id, err := bucket.Key.Int64()
+		if err != nil {
+			return nil, &QueryError{URL: s.esURL, Index: index, Err: err}
+		}
+		runs = append(runs, int(id))
+	}
+	return runs, nil
+}
+
+func (s *dslStore) index(ctx context.Context, index string, docs []document) error {
+	var body bytes.Buffer
+	for i := range docs {
+		action, err := json.Marshal(map[string]interface{}{"index": map[string]interface{}{"_id": docs[i].id}})
+		if err != nil {
+			return err
+		}
+		source, err := json.Marshal(docs[i].source)
+		if err != nil {
+			return err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(source)
+		body.WriteByte('\n')
+	}
+
+	res, err := s.send(ctx, index, func() (*rawResponse, error) {
+		return s.client.bulk(ctx, index, body.Bytes())
+	})
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(res.body, &response); err != nil {
+		return &QueryError{URL: s.esURL, Index: index, Err: err}
+	}
+	if !response.Errors {
+		return nil
+	}
+
+	failed, reason := 0, "unknown error"
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Status < http.StatusMultipleChoices {
+				continue
+			}
+			if failed == 0 && result.Error != nil {
+				reason = result.Error.Reason
+			}
+			failed++
+		}
+	}
+	return &QueryError{URL: s.esURL, Index: index,
+		Err: fmt.Errorf("%d document(s) not indexed, first error: %s", failed, reason)}
+}
+
+// readResponse reads the status and body of a response of the
+// go-elasticsearch or opensearch-go client.
+func readResponse(status int, body io.ReadCloser) (*rawResponse, error) {
+	res := &rawResponse{status: status}
+	if body == nil {
+		return res, nil
+	}
+	defer body.Close()
+
+	var err error
+	res.body, err = io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// es8Client sends requests with the API of the go-elasticsearch client.
+type es8Client struct {
+	transport esapi.Transport
+}
+
+// readES8Response returns the status and body of res.
+func readES8Response(res *esapi.Response, err error) (*rawResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return readResponse(res.StatusCode, res.Body)
+}
+
+func (c es8Client) indexExists(ctx context.Context, index string) (*rawResponse, error) {
+	return readES8Response(esapi.IndicesExistsRequest{Index: []string{index}}.Do(ctx, c.transport))
+}
+
+func (c es8Client) getMapping(ctx context.Context, index string) (*rawResponse, error) {
+	return readES8Response(esapi.IndicesGetMappingRequest{Index: []string{index}}.Do(ctx, c.transport))
+}
+
+func (c es8Client) createIndex(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readES8Response(esapi.IndicesCreateRequest{Index: index, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
+
+func (c es8Client) search(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readES8Response(esapi.SearchRequest{Index: []string{index}, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
+
+func (c es8Client) bulk(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readES8Response(esapi.BulkRequest{Index: index, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
+
+// openSearchClient sends requests with the API of the opensearch-go client.
+type openSearchClient struct {
+	transport opensearchapi.Transport
+}
+
+// readOpenSearchResponse returns the status and body of res.
+func readOpenSearchResponse(res *opensearchapi.Response, err error) (*rawResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return readResponse(res.StatusCode, res.Body)
+}
+
+func (c openSearchClient) indexExists(ctx context.Context, index string) (*rawResponse, error) {
+	return readOpenSearchResponse(opensearchapi.IndicesExistsRequest{Index: []string{index}}.Do(ctx, c.transport))
+}
+
+func (c openSearchClient) getMapping(ctx context.Context, index string) (*rawResponse, error) {
+	return readOpenSearchResponse(opensearchapi.IndicesGetMappingRequest{Index: []string{index}}.Do(ctx, c.transport))
+}
+
+func (c openSearchClient) createIndex(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readOpenSearchResponse(opensearchapi.IndicesCreateRequest{Index: index, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
+
+func (c openSearchClient) search(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readOpenSearchResponse(opensearchapi.SearchRequest{Index: []string{index}, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
+
+func (c openSearchClient) bulk(ctx context.Context, index string, body []byte) (*rawResponse, error) {
+	return readOpenSearchResponse(opensearchapi.BulkRequest{Index: index, Body: bytes.NewReader(body)}.Do(ctx, c.transport))
+}
@@ -0,0 +1,347 @@
+package es_utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// Responses of Elasticsearch 8.11 and OpenSearch 2.11, which share their
+// shape: no _type, hits total as an object.
+const (
+	dslResultsResponse = `{"took":4,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":2,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e","_id":"vcs-2927-3f6c1b2a9d0e4f57","_score":null,"_source":{"name":"upgrade","description":"E2E Suite","maintainer":"alice","durationInMinutes":12.5,"durationInSeconds":750000000000,"result":"failed","environment":"vcs","run":2927,"startTime":"2026-10-01T02:00:00Z","serial":true,"failureMessage":"timed out"},"sort":[2927]},
+{"_index":"cs_e2e","_id":"vcs-2926-3f6c1b2a9d0e4f57","_score":null,"_source":{"name":"upgrade","description":"E2E Suite","maintainer":"alice","durationInMinutes":11,"durationInSeconds":660000000000,"result":"passed","environment":"vcs","run":2926,"startTime":"2026-09-30T02:00:00Z","serial":true},"sort":[2926]}]}}`
+	dslRunsResponse = `{"took":1,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":7,"relation":"eq"},"max_score":null,"hits":[]},
+"aggregations":{"run":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":2927,"doc_count":2},{"key":2926,"doc_count":5}]}}}`
+	dslReportsResponse = `{"took":2,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":1,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e_entries","_id":"ucs-1044-9a8b7c6d5e4f3a2b","_score":null,"_source":{"type":"deploy","name":"small","subType":"3-nodes","durationInMinutes":20,"environment":"ucs","run":1044,"createdTime":"2026-10-01T03:00:00Z"},"sort":[1044]}]}}`
+	dslUsageResponse = `{"took":2,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":1,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e_usage_entries","_id":"vcs-2927-0f1e2d3c4b5a6978","_score":null,"_source":{"name":"kube-system/controller","memory":900,"cpu":120,"memoryLimit":1000,"cpuLimit":500,"environment":"vcs","run":2927,"createdTime":"2026-10-01T04:00:00Z"},"sort":[2927]}]}}`
+	dslCreateIndexResponse = `{"acknowledged":true,"shards_acknowledged":true,"index":"cs_e2e"}`
+	dslBadQueryResponse    = `{"error":{"root_cause":[{"type":"query_shard_exception","reason":"failed to create query: For input string: \"last\"","index_uuid":"kP3uT0rNQ3yZ1lF6cJgEtA","index":"cs_e2e"}],
+"type":"search_phase_execution_exception","reason":"all shards failed","phase":"query","grouped":true},"status":400}`
+	dslUnavailableResponse = `{"error":{"root_cause":[{"type":"cluster_block_exception","reason":"blocked by: [SERVICE_UNAVAILABLE/1/state not recovered / initialized];"}],
+"type":"cluster_block_exception","reason":"blocked by: [SERVICE_UNAVAILABLE/1/state not recovered / initialized];"},"status":503}`
+)
+
+// recordedRequest is a request received by a backendStandIn.
+type recordedRequest struct {
+	method string
+	path   string
+	accept string
+	body   string
+}
+
+// backendStandIn is an Elasticsearch 8 or OpenSearch stand-in. Indices are
+// created by PUT requests; searches of an index are answered with a canned
+// response in the format of the backend.
+type backendStandIn struct {
+	// product, if set, is the X-Elastic-Product header of responses
+	product string
+	// searchStatus, if set, makes searches fail with this status
+	searchStatus int
+	// rejected, if set, makes bulk items with this id fail
+	rejected string
+
+	mu       sync.Mutex
+	indices  map[string]bool
+	requests []recordedRequest
+}
+
+func (es *backendStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.requests = append(es.requests, recordedRequest{method: r.Method, path: r.URL.Path,
+		accept: r.Header.Get("Accept"), body: string(body)})
+
+	if es.product != "" {
+		w.Header().Set("X-Elastic-Product", es.product)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	index := parts[0]
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		if !es.indices[index] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(parts) == 1 && r.Method == http.MethodPut && index != "":
+		es.indices[index] = true
+		_, _ = w.Write([]byte(dslCreateIndexResponse))
+	case len(parts) == 2 && parts[1] == "_mapping":
+		fmt.Fprintf(w, `{%q:{"mappings":{"_meta":{%q:%d},"properties":{"run":{"type":"long"}}}}}`,
+			index, schemaVersionKey, SchemaVersion)
+	case len(parts) == 2 && parts[1] == "_search":
+		switch {
+		case es.searchStatus == http.StatusServiceUnavailable:
+			w.WriteHeader(es.searchStatus)
+			_, _ = w.Write([]byte(dslUnavailableResponse))
+		case es.searchStatus != 0:
+			w.WriteHeader(es.searchStatus)
+			_, _ = w.Write([]byte(dslBadQueryResponse))
+		case strings.Contains(string(body), `"aggs"`):
+			_, _ = w.Write([]byte(dslRunsResponse))
+		default:
+			_, _ = w.Write([]byte(map[string]string{
+				resultCloudstackIndex: dslResultsResponse,
+				reportCloudstackIndex: dslReportsResponse,
+				usageCloudstackIndex:  dslUsageResponse,
+			}[index]))
+		}
+	case len(parts) == 2 && parts[1] == "_bulk":
+		es.bulk(w, index, body)
+	default:
+		// Requests of the Elasticsearch 7 client, such as its version
+		// check on GET /, are not expected.
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// bulk answers a bulk request of index actions as the backend does.
+func (es *backendStandIn) bulk(w http.ResponseWriter, index string, body []byte) {
+	items := make([]string, 0)
+	failed := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var action struct {
+			Index struct {
+				ID string `json:"_id"`
+			} `json:"index"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if action.Index.ID == es.rejected {
+			failed = true
+			items = append(items, fmt.Sprintf(`{"index":{"_index":%q,"_id":%q,"status":400,"error":{"type":"document_parsing_exception",`+
+				`"reason":"[1:58] failed to parse field [run] of type [long] in document with id '%s'"}}}`,
+				index, action.Index.ID, action.Index.ID))
+			continue
+		}
+		items = append(items, fmt.Sprintf(`{"index":{"_index":%q,"_id":%q,"_version":1,"result":"created",`+
+			`"_shards":{"total":2,"successful":1,"failed":0},"_seq_no":0,"_primary_term":1,"status":201}}`,
+			index, action.Index.ID))
+	}
+	fmt.Fprintf(w, `{"took":12,"errors":%t,"items":[%s]}`, failed, strings.Join(items, ","))
+}
+
+// recorded returns the requests received, other than index checks.
+func (es *backendStandIn) recorded() []recordedRequest {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	requests := make([]recordedRequest, 0, len(es.requests))
+	for _, r := range es.requests {
+		if r.method != http.MethodHead && !strings.HasSuffix(r.path, "/_mapping") {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// newBackendStandIn starts es with indices and configures the store to use
+// it with backend.
+func newBackendStandIn(t *testing.T, backend Backend, es *backendStandIn, indices ...string) {
+	t.Helper()
+	es.indices = make(map[string]bool)
+	for _, index := range indices {
+		es.indices[index] = true
+	}
+	server := httptest.NewServer(es)
+	SetRetryPolicy(RetryPolicy{})
+	if err := SetStore(&StoreConfig{Backend: backend, URL: server.URL}); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = SetStore(&StoreConfig{})
+		SetRetryPolicy(DefaultRetryPolicy)
+		server.Close()
+	})
+}
+
+// jsonEqual returns true if a and b are the same JSON value.
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal([]byte(a), &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestDSLStore(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range []struct {
+		backend Backend
+		product string
+	}{
+		{backend: Elasticsearch8, product: "Elasticsearch"},
+		{backend: OpenSearch},
+	} {
+		es := &backendStandIn{product: backend.product}
+		newBackendStandIn(t, backend.backend, es, reportCloudstackIndex, usageCloudstackIndex)
+
+		// Pushing results creates the missing index with its mapping.
+		pushed := []Result{{Name: "upgrade", Result: "passed", Environment: "vcs", Run: 2928}}
+		if err := PushResults(ctx, logr.Discard(), pushed); err != nil {
+			t.Fatalf("%s: PushResults failed: %v", backend.backend, err)
+		}
+
+		results, err := GetResults(ctx, logr.Discard(), ResultFilter{Environment: "vcs", Run: "2927",
+			Test: "upgrade", Serial: true, Max: 50})
+		if err != nil {
+			t.Fatalf("%s: GetResults failed: %v", backend.backend, err)
+		}
+		if len(results) != 2 || results[0].Run != 2927 || results[0].FailureMessage != "timed out" ||
+			results[1].Result != "passed" || results[1].DurationInSecond.Minutes() != 11 {
+			t.Errorf("%s: results are %+v", backend.backend, results)
+		}
+
+		reports, err := GetReports(ctx, logr.Discard(), "1044", "deploy", "3-nodes", "", false, true, 10)
+		if err != nil {
+			t.Fatalf("%s: GetReports failed: %v", backend.backend, err)
+		}
+		if len(reports) != 1 || reports[0].SubType != "3-nodes" || reports[0].DurationInMinutes != 20 {
+			t.Errorf("%s: reports are %+v", backend.backend, reports)
+		}
+
+		usage, err := GetUsageReports(ctx, logr.Discard(), "", "kube-system/controller", true, false, 10)
+		if err != nil {
+			t.Fatalf("%s: GetUsageReports failed: %v", backend.backend, err)
+		}
+		if len(usage) != 1 || usage[0].Memory != 900 || usage[0].CPULimit != 500 {
+			t.Errorf("%s: usage reports are %+v", backend.backend, usage)
+		}
+
+		runs, err := GetAvailableRuns(ctx, "vcs", 20, logr.Discard())
+		if err != nil {
+			t.Fatalf("%s: GetAvailableRuns failed: %v", backend.backend, err)
+		}
+		if !reflect.DeepEqual(runs, []int{2927, 2926}) {
+			t.Errorf("%s: runs are %v, want [2927 2926]", backend.backend, runs)
+		}
+
+		// Requests are the ones of the backend API, with the query
+		// semantics of the Elasticsearch 7 queries.
+		sort := `"sort":[{"run":{"order":"desc","mode":"max"}}]`
+		want := []recordedRequest{
+			{method: http.MethodPut, path: "/cs_e2e",
+				body: fmt.Sprintf(`{"mappings":{"_meta":{%q:%d},"properties":%s}}`,
+					schemaVersionKey, SchemaVersion, mustJSON(t, resultProperties))},
+			{method: http.MethodPost, path: "/cs_e2e/_bulk",
+				body: fmt.Sprintf(`{"index":{"_id":%q}}`+"\n"+`%s`+"\n", ResultID(&pushed[0]), mustJSON(t, pushed[0]))},
+			{method: http.MethodPost, path: "/cs_e2e/_search",
+				body: `{"query":{"bool":{"filter":[{"match":{"environment":{"query":"vcs"}}},{"term":{"serial":true}},` +
+					`{"match":{"run":{"query":"2927"}}},{"term":{"name.keyword":"upgrade"}}]}},"size":50,` + sort + `}`},
+			{method: http.MethodPost, path: "/cs_e2e_entries/_search",
+				body: `{"query":{"bool":{"filter":[{"match":{"environment":{"query":"ucs"}}},{"match":{"run":{"query":"1044"}}},` +
+					`{"match":{"type":{"query":"deploy"}}},{"term":{"subType.keyword":"3-nodes"}}]}},"size":10,` + sort + `}`},
+			{method: http.MethodPost, path: "/cs_e2e_usage_entries/_search",
+				body: `{"query":{"bool":{"filter":[{"match":{"environment":{"query":"vcs"}}},` +
+					`{"term":{"name.keyword":"kube-system/controller"}}]}},"size":10,` + sort + `}`},
+			{method: http.MethodPost, path: "/cs_e2e/_search",
+				body: `{"size":0,"query":{"match":{"environment":{"query":"vcs"}}},` +
+					`"aggs":{"run":{"terms":{"field":"run","size":20,"order":{"_key":"desc"}}}}}`},
+		}
+		requests := es.recorded()
+		if len(requests) != len(want) {
+			t.Fatalf("%s: requests are %+v, want %d requests", backend.backend, requests, len(want))
+		}
+		for i := range want {
+			got := requests[i]
+			if got.method != want[i].method || got.path != want[i].path {
+				t.Errorf("%s: request %d is %s %s, want %s %s", backend.backend, i,
+					got.method, got.path, want[i].method, want[i].path)
+			}
+			if strings.Contains(got.accept, "compatible-with=7") {
+				t.Errorf("%s: request %d asks for the compatibility with version 7", backend.backend, i)
+			}
+			if strings.HasSuffix(got.path, "/_bulk") {
+				if got.body != want[i].body {
+					t.Errorf("%s: bulk body is %q, want %q", backend.backend, got.body, want[i].body)
+				}
+			} else if !jsonEqual(t, got.body, want[i].body) {
+				t.Errorf("%s: request %d body is %s, want %s", backend.backend, i, got.body, want[i].body)
+			}
+		}
+	}
+}
+
+// mustJSON returns v encoded as JSON.
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal %v: %v", v, err)
+	}
+	return string(b)
+}
+
+func TestDSLStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	indices := []string{resultCloudstackIndex, reportCloudstackIndex, usageCloudstackIndex}
+
+	tests := []struct {
+		name    string
+		backend Backend
+		es      *backendStandIn
+		indices []string
+		push    bool
+		wantErr interface{}
+		want    string
+	}{
+		{name: "not Elasticsearch", backend: Elasticsearch8, es: &backendStandIn{}, indices: indices,
+			wantErr: &QueryError{}, want: "the client noticed that the server is not Elasticsearch"},
+		{name: "missing index", backend: OpenSearch, es: &backendStandIn{},
+			wantErr: &IndexNotFoundError{}, want: `index "cs_e2e" does not exist`},
+		{name: "bad query", backend: Elasticsearch8, es: &backendStandIn{product: "Elasticsearch", searchStatus: http.StatusBadRequest},
+			indices: indices, wantErr: &QueryError{}, want: "400 Bad Request: all shards failed [type=search_phase_execution_exception]"},
+		{name: "unavailable", backend: OpenSearch, es: &backendStandIn{searchStatus: http.StatusServiceUnavailable},
+			indices: indices, wantErr: &ConnectionError{}, want: "503 Service Unavailable: blocked by"},
+		{name: "rejected document", backend: Elasticsearch8,
+			es:      &backendStandIn{product: "Elasticsearch", rejected: ResultID(&Result{Name: "upgrade", Environment: "vcs", Run: 2928})},
+			indices: indices, push: true, wantErr: &QueryError{},
+			want: "1 document(s) not indexed, first error: [1:58] failed to parse field [run]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newBackendStandIn(t, tt.backend, tt.es, tt.indices...)
+
+			var err error
+			if tt.push {
+				err = PushResults(ctx, logr.Discard(), []Result{
+					{Name: "install", Environment: "vcs", Run: 2928}, {Name: "upgrade", Environment: "vcs", Run: 2928}})
+			} else {
+				_, err = GetResults(ctx, logr.Discard(), ResultFilter{Environment: "vcs", Max: 10})
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+			if target := reflect.New(reflect.TypeOf(tt.wantErr)).Interface(); !errors.As(err, target) {
+				t.Errorf("error is %T, want %T", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package es_utils
+
+import (
+	"fmt"
+)
+
+// ConnectionError is returned when Elasticsearch cannot be reached,
+// even after all configured retries.
+type ConnectionError struct {
+	// URL is the Elasticsearch URL that was tried
+	URL string
+	// Attempts is the number of connection attempts made
+	Attempts int
+	// Err is the last error seen
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("cannot reach Elasticsearch at %s after %d attempt(s): %v. "+
+		"Verify the host is up and reachable from this machine (retries can be tuned with --es-retries and --es-backoff)",
+		e.URL, e.Attempts, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// IndexNotFoundError is returned when Elasticsearch is reachable but
+// the expected index does not exist.
+type IndexNotFoundError struct {
+	// URL is the Elasticsearch URL that was queried
+	URL string
+	// Index is the missing index
+	Index string
+}
+
+func (e *IndexNotFoundError) Error() string {
+	return fmt.Sprintf("index %q does not exist on Elasticsearch at %s. "+
+		"Verify results were pushed to this cluster", e.Index, e.URL)
+}
+
+// QueryError is returned when Elasticsearch is reachable but a request
+// against an index fails.
+type QueryError struct {
+	// URL is the Elasticsearch URL that was queried
+	URL string
+	// Index is the index the query was run against
+	Index string
+	// Err is the error returned by Elasticsearch
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query on index %q at %s failed: %v", e.Index, e.URL, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
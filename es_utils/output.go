@@ -0,0 +1,59 @@
+package es_utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// OutputFormat defines how show commands print entries.
+type OutputFormat string
+
+const (
+	// TableOutput prints an ASCII table (default)
+	TableOutput OutputFormat = "table"
+	// JSONOutput prints a JSON array of entries
+	JSONOutput OutputFormat = "json"
+	// CSVOutput prints comma separated values with a header line
+	CSVOutput OutputFormat = "csv"
+)
+
+// ParseOutputFormat validates format. An empty format means TableOutput.
+func ParseOutputFormat(format string) (OutputFormat, error) {
+	switch OutputFormat(format) {
+	case "", TableOutput:
+		return TableOutput, nil
+	case JSONOutput, CSVOutput:
+		return OutputFormat(format), nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (valid values are table, json and csv)", format)
+	}
+}
+
+// printEntries prints entries in the requested format. Table and CSV outputs
+// print header and rows; JSON output marshals items.
+func printEntries(format OutputFormat, header []string, rows [][]string, items interface{}) error {
+	switch format {
+	case JSONOutput:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	case CSVOutput:
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		return w.WriteAll(rows)
+	default:
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(header)
+		table.SetAutoWrapText(false)
+		table.SetRowLine(true)
+		table.AppendBulk(rows)
+		table.Render()
+		return nil
+	}
+}
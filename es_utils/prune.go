@@ -0,0 +1,371 @@
+package es_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// runsPageSize is the number of runs aggregated per request when looking
+// for the runs to prune.
+const runsPageSize = 1000
+
+// PruneOptions selects the runs whose documents are pruned. Runs are pruned
+// as a whole, from all indices, so that no run is left with only part of its
+// data.
+type PruneOptions struct {
+	// OlderThan, if set, prunes the runs all of whose documents were produced
+	// before OlderThan, i.e. whose newest document, in any index, is older.
+	// Documents with no time are ignored: runs with no time at all are not
+	// pruned by age.
+	OlderThan time.Time
+	// KeepRuns, if positive, prunes all runs but the KeepRuns most recent
+	// ones. Runs are the ones with results. If OlderThan is set too, runs
+	// selected by either are pruned.
+	KeepRuns int
+	// Environments are the environments whose runs are pruned
+	Environments []string
+}
+
+// IndexPrune reports the documents of an environment pruned from an index.
+type IndexPrune struct {
+	// URL is the Elasticsearch URL index is stored at
+	URL string
+	// Index is the index name
+	Index string
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Runs are the pruned runs, oldest first. Empty if no run is pruned.
+	Runs []int
+	// Documents is the number of documents to prune
+	Documents int64
+	// Deleted is the number of documents deleted
+	Deleted int64
+}
+
+// PlanPrune returns, for each environment and index, the runs to prune and
+// their number of documents. Nothing is deleted. Indices which do not exist
+// are skipped.
+func PlanPrune(ctx context.Context, logger logr.Logger, options PruneOptions) ([]IndexPrune, error) {
+	plans := make([]IndexPrune, 0)
+	for _, env := range options.Environments {
+		runs, err := prunedRuns(ctx, logger, env, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range managedIndices {
+			m := &managedIndices[i]
+			c, err := GetClient(m.esURL())
+			if err != nil {
+				logger.Error(err, "Failed to get client")
+				return nil, err
+			}
+			if err := VerifyIndex(ctx, logger, c, m.esURL(), m.index); err != nil {
+				var notFound *IndexNotFoundError
+				if errors.As(err, &notFound) {
+					continue
+				}
+				logger.Error(err, "Failed to verify index")
+				return nil, err
+			}
+
+			plan := IndexPrune{URL: m.esURL(), Index: m.index, Environment: env, Runs: runs}
+			if len(runs) > 0 {
+				err = runRequest(ctx, m.esURL(), m.index, func() error {
+					var err error
+					plan.Documents, err = c.Count(m.index).Query(pruneQuery(env, runs)).Do(ctx)
+					return err
+				})
+				if err != nil {
+					logger.Error(err, "Failed to count documents")
+					return nil, err
+				}
+			}
+			plans = append(plans, plan)
+		}
+	}
+
+	return plans, nil
+}
+
+// Prune deletes the documents selected by plans, as returned by PlanPrune,
+// and records the number of documents deleted.
+func Prune(ctx context.Context, logger logr.Logger, plans []IndexPrune) error {
+	for i := range plans {
+		plan := &plans[i]
+		if plan.Documents == 0 {
+			continue
+		}
+
+		c, err := GetClient(plan.URL)
+		if err != nil {
+			logger.Error(err, "Failed to get client")
+			return err
+		}
+
+		err = runRequest(ctx, plan.URL, plan.Index, func() error {
+			response, err := c.DeleteByQuery(plan.Index).
+				Query(pruneQuery(plan.Environment, plan.Runs)).
+				ProceedOnVersionConflict().
+				Refresh("true").
+				Do(ctx)
+			if err != nil {
+				return err
+			}
+			plan.Deleted = response.Deleted
+			return nil
+		})
+		if err != nil {
+			logger.Error(err, "Failed to delete documents", "index", plan.Index)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneQuery matches the documents of the given runs of env.
+func pruneQuery(env string, runs []int) elastic.Query {
+	runIDs := make([]interface{}, len(runs))
+	for i := range runs {
+		runIDs[i] = runs[i]
+	}
+	return elastic.NewBoolQuery().
+		Filter(elastic.NewMatchQuery("environment", env)).
+		Filter(elastic.NewTermsQuery("run", runIDs...))
+}
+
+// prunedRuns returns the runs of env to prune, oldest first.
+func prunedRuns(ctx context.Context, logger logr.Logger, env string, options PruneOptions) ([]int, error) {
+	newest, err := newestDocuments(ctx, logger, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs to keep are the most recent ones.
+	recent := make([]int, 0)
+	if options.KeepRuns > 0 {
+		recent, err = GetAvailableRuns(ctx, env, options.KeepRuns, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return selectPrunedRuns(newest, recent, options), nil
+}
+
+// selectPrunedRuns returns, sorted, the runs to prune. newest maps each run
+// to the time of its newest document (zero if unknown) and recent contains
+// the most recent runs, at most options.KeepRuns of them.
+// A run is pruned if it precedes all recent runs while KeepRuns of them
+// exist, or if its newest document is older than options.OlderThan.
+func selectPrunedRuns(newest map[int]time.Time, recent []int, options PruneOptions) []int {
+	keepFrom := 0
+	if options.KeepRuns > 0 && len(recent) == options.KeepRuns {
+		for i, run := range recent {
+			if i == 0 || run < keepFrom {
+				keepFrom = run
+			}
+		}
+	}
+
+	runs := make([]int, 0)
+	for run, t := range newest {
+		pruned := keepFrom > 0 && run < keepFrom
+		if !options.OlderThan.IsZero() && !t.IsZero() && t.Before(options.OlderThan) {
+			pruned = true
+		}
+		if pruned {
+			runs = append(runs, run)
+		}
+	}
+	sort.Ints(runs)
+
+	return runs
+}
+
+// newestDocuments returns, for every run of env with a document in any
+// index, the time its newest document was produced. The time is zero if no
+// document of the run has one.
+func newestDocuments(ctx context.Context, logger logr.Logger, env string) (map[int]time.Time, error) {
+	// Zero times are marshaled as 0001-01-01T00:00:00Z.
+	epoch := float64(time.Unix(0, 0).UnixMilli())
+
+	newest := make(map[int]time.Time)
+	for i := range managedIndices {
+		m := &managedIndices[i]
+		c, err := GetClient(m.esURL())
+		if err != nil {
+			logger.Error(err, "Failed to get client")
+			return nil, err
+		}
+		if err := VerifyIndex(ctx, logger, c, m.esURL(), m.index); err != nil {
+			var notFound *IndexNotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			}
+			logger.Error(err, "Failed to verify index")
+			return nil, err
+		}
+
+		// Runs are paged through: there can be more runs than a single
+		// aggregation returns.
+		var after map[string]interface{}
+		for {
+			aggr := elastic.NewCompositeAggregation().Size(runsPageSize).
+				Sources(elastic.NewCompositeAggregationTermsValuesSource("run").Field("run")).
+				SubAggregation("newest", elastic.NewMaxAggregation().Field(m.timeField))
+			if after != nil {
+				aggr = aggr.AggregateAfter(after)
+			}
+			searchResult, err := runQuery(ctx, m.esURL(), m.index, func() (*elastic.SearchResult, error) {
+				return c.Search().Index(m.index).
+					Query(elastic.NewMatchQuery("environment", env)).
+					Size(0).
+					Aggregation("runs", aggr).
+					Do(ctx)
+			})
+			if err != nil {
+				logger.Error(err, "Failed to run query")
+				return nil, err
+			}
+
+			b, found := searchResult.Aggregations.Composite("runs")
+			if !found {
+				return nil, fmt.Errorf("failed to get runs of index %s", m.index)
+			}
+			for _, bucket := range b.Buckets {
+				id, ok := bucket.Key["run"].(float64)
+				if !ok {
+					continue
+				}
+				run := int(id)
+				t := newest[run]
+				if value, found := bucket.Max("newest"); found && value.Value != nil && *value.Value > epoch {
+					if mt := time.UnixMilli(int64(*value.Value)); mt.After(t) {
+						t = mt
+					}
+				}
+				newest[run] = t
+			}
+			if len(b.Buckets) < runsPageSize || b.AfterKey == nil {
+				break
+			}
+			after = b.AfterKey
+		}
+	}
+
+	return newest, nil
+}
+
+// boundaryRun returns the most recent run (the oldest if oldest is set) of
+// env with a document of index m produced at or after from and before to,
+// zero if none. A zero to sets no upper bound. Documents with no time are
+// ignored.
+func boundaryRun(ctx context.Context, logger logr.Logger, m *managedIndex, env string,
+	from, to time.Time, oldest bool,
+) (int, error) {
+	c, err := GetClient(m.esURL())
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return 0, err
+	}
+
+	if err = VerifyIndex(ctx, logger, c, m.esURL(), m.index); err != nil {
+		var notFound *IndexNotFoundError
+		if errors.As(err, &notFound) {
+			return 0, nil
+		}
+		logger.Error(err, "Failed to verify index")
+		return 0, err
+	}
+
+	// Zero times are marshaled as 0001-01-01T00:00:00Z.
+	epoch := time.Unix(0, 0)
+	if from.Before(epoch) {
+		from = epoch
+	}
+	timeRange := elastic.NewRangeQuery(m.timeField).Gte(from.UTC().Format(time.RFC3339))
+	if !to.IsZero() {
+		timeRange = timeRange.Lt(to.UTC().Format(time.RFC3339))
+	}
+	query := elastic.NewBoolQuery().
+		Filter(elastic.NewMatchQuery("environment", env)).
+		Filter(timeRange)
+	searchResult, err := runQuery(ctx, m.esURL(), m.index, func() (*elastic.SearchResult, error) {
+		return c.Search().Index(m.index).Query(query).Size(1).
+			SortBy(elastic.NewFieldSort("run").Order(oldest)).
+			Do(ctx)
+	})
+	if err != nil {
+		logger.Error(err, "Failed to run query")
+		return 0, err
+	}
+
+	type runDocument struct {
+		Run int `json:"run"`
+	}
+	var rtyp runDocument
+	for _, item := range searchResult.Each(reflect.TypeOf(rtyp)) {
+		return item.(runDocument).Run, nil
+	}
+
+	return 0, nil
+}
+
+// DisplayPrunePlan displays, for each environment and index, the runs and
+// the number of documents pruned.
+func DisplayPrunePlan(plans []IndexPrune, deleted bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"INDEX", "ENVIRONMENT", "PRUNED RUNS", "DOCUMENTS"}
+	if deleted {
+		header = append(header, "DELETED")
+	}
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for i := range plans {
+		p := &plans[i]
+		runs := "none"
+		if len(p.Runs) > 0 {
+			runs = formatRuns(p.Runs)
+		}
+		row := []string{p.Index, p.Environment, runs, strconv.FormatInt(p.Documents, 10)}
+		if deleted {
+			row = append(row, strconv.FormatInt(p.Deleted, 10))
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+}
+
+// formatRuns returns sorted runs as a list of ranges, e.g. 1-5, 8.
+func formatRuns(runs []int) string {
+	ranges := make([]string, 0)
+	for i := 0; i < len(runs); {
+		j := i
+		for j+1 < len(runs) && runs[j+1] == runs[j]+1 {
+			j++
+		}
+		if j == i {
+			ranges = append(ranges, strconv.Itoa(runs[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", runs[i], runs[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
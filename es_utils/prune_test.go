@@ -0,0 +1,38 @@
+package es_utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectPrunedRuns(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC) }
+	// Run 100 has no timed document: only keep-runs can prune it.
+	newest := map[int]time.Time{100: {}, 101: day(1), 102: day(3), 103: day(5), 104: day(7)}
+
+	tests := []struct {
+		name    string
+		recent  []int
+		options PruneOptions
+		want    []int
+	}{
+		{name: "nothing to prune", want: []int{}},
+		{name: "keep runs", recent: []int{104, 103}, options: PruneOptions{KeepRuns: 2},
+			want: []int{100, 101, 102}},
+		{name: "fewer runs than kept", recent: []int{104, 103}, options: PruneOptions{KeepRuns: 10},
+			want: []int{}},
+		{name: "older than cutoff", options: PruneOptions{OlderThan: day(4)}, want: []int{101, 102}},
+		{name: "newest document on the cutoff is kept", options: PruneOptions{OlderThan: day(3)},
+			want: []int{101}},
+		{name: "keep runs and older than", recent: []int{104, 103, 102}, options: PruneOptions{KeepRuns: 3, OlderThan: day(4)},
+			want: []int{100, 101, 102}},
+		{name: "recent runs are pruned by age", recent: []int{104}, options: PruneOptions{KeepRuns: 1, OlderThan: day(6)},
+			want: []int{100, 101, 102, 103}},
+	}
+	for _, tt := range tests {
+		if got := selectPrunedRuns(newest, tt.recent, tt.options); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: selectPrunedRuns() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
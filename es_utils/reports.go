@@ -2,22 +2,20 @@ package es_utils
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"reflect"
 	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/olekukonko/tablewriter"
-	elastic "github.com/olivere/elastic/v7"
-)
 
-const (
-	reportCloudstackESURL = "http://172.31.165.56:9200"
-	reportCloudstackIndex = "cs_e2e_entries"
+	"github.com/gianlucam76/cs-e2e-result/slo"
 )
 
+const reportCloudstackESURL = "http://172.31.165.56:9200"
+
+const reportCloudstackIndex = "cs_e2e_entries"
+
 type Report struct {
 	// Type of the report
 	Type string `json:"type"`
@@ -42,88 +40,117 @@ type Report struct {
 	CreatedTime time.Time `json:"createdTime"`
 }
 
+// GetReports returns reports matching the filters, most recent run first.
 func GetReports(ctx context.Context, logger logr.Logger,
 	run, reportType, reportSubType, reportName string,
 	vcs, ucs bool, maxResult int,
-) (*elastic.SearchResult, error) {
-	c, err := GetClient(reportCloudstackESURL)
+) ([]Report, error) {
+	s, err := getStore(storeURL(reportCloudstackESURL))
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to get client: %v", err))
+		logger.Error(err, "Failed to get client")
 		return nil, err
 	}
 
-	if err = VerifyIndex(ctx, c, reportCloudstackIndex); err != nil {
-		logger.Info(fmt.Sprintf("Failed to verify index %v", err))
+	if err = s.verifyIndex(ctx, logger, reportCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to verify index")
 		return nil, err
 	}
 
-	generalQ := elastic.NewBoolQuery().Should()
+	filters := make([]searchFilter, 0)
 
 	if vcs {
 		logger.Info("Filter by environment:vcs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "vcs"))
+		filters = append(filters, searchFilter{field: "environment", value: "vcs"})
 	} else if ucs {
 		logger.Info("Filter by environment:ucs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "ucs"))
+		filters = append(filters, searchFilter{field: "environment", value: "ucs"})
 	}
 
 	if run != "" {
 		logger.Info(fmt.Sprintf("Filter by run:%s", run))
-		generalQ.Filter(elastic.NewMatchQuery("run", run))
+		filters = append(filters, searchFilter{field: "run", value: run})
 	}
 
 	if reportType != "" {
 		logger.Info(fmt.Sprintf("Filter by reportType:%s", reportType))
-		generalQ.Filter(elastic.NewMatchQuery("type", reportType))
+		filters = append(filters, searchFilter{field: "type", value: reportType})
 	}
 
 	if reportSubType != "" {
 		logger.Info(fmt.Sprintf("Filter by reportSubType:%s", reportSubType))
-		generalQ.Filter(elastic.NewTermQuery("subType.keyword", reportSubType))
+		filters = append(filters, searchFilter{field: "subType.keyword", value: reportSubType, exact: true})
 	}
 
 	if reportName != "" {
 		logger.Info(fmt.Sprintf("Filter by report name:%s", reportName))
-		generalQ.Filter(elastic.NewTermQuery("name.keyword", reportName)) // Exact match
+		filters = append(filters, searchFilter{field: "name.keyword", value: reportName, exact: true})
 	}
 
-	searchResult, err := c.Search().Index(reportCloudstackIndex).Query(generalQ).Size(maxResult).
-		SortBy(elastic.NewFieldSort("run").Desc().SortMode("max")).
-		Pretty(true).            // pretty print request and response JSON
-		Do(context.Background()) // execute
+	response, err := s.search(ctx, reportCloudstackIndex, filters, maxResult)
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to run query %v", err))
+		logger.Error(err, "Failed to run query")
 		return nil, err
 	}
 
-	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", searchResult.TookInMillis))
+	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", response.took))
+
+	reports := make([]Report, len(response.sources))
+	for i, source := range response.sources {
+		if err := json.Unmarshal(source, &reports[i]); err != nil {
+			return nil, &QueryError{URL: storeURL(reportCloudstackESURL), Index: reportCloudstackIndex,
+				Err: fmt.Errorf("invalid report: %w", err)}
+		}
+	}
 
-	return searchResult, nil
+	return reports, nil
 }
 
+// ReportEntry is a report as displayed by DisplayReport.
+type ReportEntry struct {
+	Report
+	// SLO is the result of checking report duration against thresholds.
+	// Empty if thresholds were not checked or none matches the report.
+	SLO slo.Status `json:"slo,omitempty"`
+	// SLOMaxMinutes is the maximum duration of the matching threshold
+	SLOMaxMinutes float64 `json:"sloMaxMinutes,omitempty"`
+}
+
+// DisplayReport displays reports matching the filters in the requested format.
+// If thresholds is not nil, each report is marked OK/WARN/BREACH.
 func DisplayReport(ctx context.Context, logger logr.Logger,
 	run, reportType, reportSubType, reportName string,
 	vcs, ucs bool,
 	maxResult int,
+	thresholds *slo.Thresholds,
+	format OutputFormat,
 ) error {
-	searchResult, err := GetReports(ctx, logger, run, reportType, reportSubType, reportName, vcs, ucs, maxResult)
+	reports, err := GetReports(ctx, logger, run, reportType, reportSubType, reportName, vcs, ucs, maxResult)
 	if err != nil {
 		return err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ENVIRONMENT", "RUN", "REPORT TYPE", "REPORT SUBTYPE", "NAME", "DURATION"})
-	table.SetAutoWrapText(false)
-	table.SetRowLine(true)
-
-	var rtyp Report
-	for _, item := range searchResult.Each(reflect.TypeOf(rtyp)) {
-		r := item.(Report)
-		table.Append([]string{r.Environment, strconv.Itoa(r.Run),
-			r.Type, r.SubType, r.Name, fmt.Sprintf("%f", r.DurationInMinutes)})
+	header := []string{"ENVIRONMENT", "RUN", "REPORT TYPE", "REPORT SUBTYPE", "NAME", "DURATION"}
+	if thresholds != nil {
+		header = append(header, "MAX", "SLO")
 	}
 
-	table.Render()
+	entries := make([]ReportEntry, len(reports))
+	rows := make([][]string, len(reports))
+	for i := range reports {
+		r := &reports[i]
+		entries[i].Report = *r
+		rows[i] = []string{r.Environment, strconv.Itoa(r.Run),
+			r.Type, r.SubType, r.Name, fmt.Sprintf("%f", r.DurationInMinutes)}
+		if thresholds != nil {
+			status, th := thresholds.Check(r.Type, r.SubType, r.Name, r.DurationInMinutes)
+			max, display := "-", "-"
+			if th != nil {
+				entries[i].SLO, entries[i].SLOMaxMinutes = status, th.MaxMinutes
+				max, display = fmt.Sprintf("%f", th.MaxMinutes), string(status)
+			}
+			rows[i] = append(rows[i], max, display)
+		}
+	}
 
-	return nil
+	return printEntries(format, header, rows, entries)
 }
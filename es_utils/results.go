@@ -2,22 +2,23 @@ package es_utils
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/olekukonko/tablewriter"
 	elastic "github.com/olivere/elastic/v7"
-)
 
-const (
-	resultCloudstackESURL = "http://172.31.165.56:9200"
-	resultCloudstackIndex = "cs_e2e"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
 )
 
+const resultCloudstackESURL = "http://172.31.165.56:9200"
+
+const resultCloudstackIndex = "cs_e2e"
+
 type Result struct {
 	// Name is the name of the test
 	Name string `json:"name"`
@@ -39,96 +40,279 @@ type Result struct {
 	StartTime time.Time `json:"startTime"`
 	// Serial indicates whether test was run in serial
 	Serial bool `json:"serial"`
+	// FailureMessage is the message test failed with. Optional.
+	FailureMessage string `json:"failureMessage,omitempty"`
+	// FailureLocation is the file:line test failed at. Optional.
+	FailureLocation string `json:"failureLocation,omitempty"`
+	// Artifacts are links to logs, must-gather bundles, screenshots and any
+	// other file collected while test ran. Optional.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
 }
 
-func GetResults(ctx context.Context, logger logr.Logger,
-	run, testName string,
-	vcs, ucs, passed, failed, skipped bool,
-	maxResult int,
-) (*elastic.SearchResult, error) {
-	c, err := GetClient(resultCloudstackESURL)
+// Artifact is a link to a file collected while a test ran.
+type Artifact struct {
+	// Name is the name of the file, i.e. its path relative to the artifacts
+	// of the test
+	Name string `json:"name"`
+	// URL is where the file can be downloaded from
+	URL string `json:"url"`
+}
+
+// ResultFilter selects results. Fields left to their zero value do not
+// filter.
+type ResultFilter struct {
+	// Environment, if set, selects results of this environment, i.e. vcs
+	// or ucs
+	Environment string
+	// Run, if set, selects results of this run
+	Run string
+	// Test, if set, selects results of this test (exact match)
+	Test string
+	// Maintainer, if set, selects results of tests maintained by Maintainer
+	// (exact match)
+	Maintainer string
+	// Result, if set, selects results with this outcome, i.e. passed,
+	// failed or skipped
+	Result string
+	// Serial selects results of tests run in serial only
+	Serial bool
+	// Parallel selects results of tests run in parallel only. Ignored if
+	// Serial is set
+	Parallel bool
+	// Max is the maximum number of results returned
+	Max int
+}
+
+// GetResults returns results matching filter, most recent run first.
+func GetResults(ctx context.Context, logger logr.Logger, filter ResultFilter) ([]Result, error) {
+	s, err := getStore(storeURL(resultCloudstackESURL))
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to get client: %v", err))
+		logger.Error(err, "Failed to get client")
 		return nil, err
 	}
 
-	if err = VerifyIndex(ctx, c, resultCloudstackIndex); err != nil {
-		logger.Info(fmt.Sprintf("Failed to verify index %v", err))
+	if err = s.verifyIndex(ctx, logger, resultCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to verify index")
 		return nil, err
 	}
 
-	generalQ := elastic.NewBoolQuery().Should()
+	filters := make([]searchFilter, 0)
 
-	if passed {
-		logger.Info("Filter by result:passed")
-		generalQ.Filter(elastic.NewMatchQuery("result", "passed"))
-	} else if failed {
-		logger.Info("Filter by result:failed")
-		generalQ.Filter(elastic.NewMatchQuery("result", "failed"))
-	} else if skipped {
-		logger.Info("Filter by result:skipped")
-		generalQ.Filter(elastic.NewMatchQuery("result", "skipped"))
+	if filter.Result != "" {
+		logger.Info(fmt.Sprintf("Filter by result:%s", filter.Result))
+		filters = append(filters, searchFilter{field: "result", value: filter.Result})
 	}
 
-	if vcs {
-		logger.Info("Filter by environment:vcs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "vcs"))
-	} else if ucs {
-		logger.Info("Filter by environment:ucs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "ucs"))
+	if filter.Environment != "" {
+		logger.Info(fmt.Sprintf("Filter by environment:%s", filter.Environment))
+		filters = append(filters, searchFilter{field: "environment", value: filter.Environment})
 	}
 
-	if run != "" {
-		logger.Info(fmt.Sprintf("Filter by run:%s", run))
-		generalQ.Filter(elastic.NewMatchQuery("run", run))
+	if filter.Serial {
+		logger.Info("Filter by serial:true")
+		filters = append(filters, searchFilter{field: "serial", value: true, exact: true})
+	} else if filter.Parallel {
+		logger.Info("Filter by serial:false")
+		filters = append(filters, searchFilter{field: "serial", value: false, exact: true})
 	}
 
-	if testName != "" {
-		logger.Info(fmt.Sprintf("Filter by test:%s", testName))
-		generalQ.Filter(elastic.NewTermQuery("name.keyword", testName)) // Exact match
+	if filter.Run != "" {
+		logger.Info(fmt.Sprintf("Filter by run:%s", filter.Run))
+		filters = append(filters, searchFilter{field: "run", value: filter.Run})
 	}
 
-	searchResult, err := c.Search().Index(resultCloudstackIndex).Query(generalQ).Size(maxResult).
-		SortBy(elastic.NewFieldSort("run").Desc().SortMode("max")).
-		Pretty(true).            // pretty print request and response JSON
-		Do(context.Background()) // execute
+	if filter.Test != "" {
+		logger.Info(fmt.Sprintf("Filter by test:%s", filter.Test))
+		filters = append(filters, searchFilter{field: "name.keyword", value: filter.Test, exact: true})
+	}
+
+	if filter.Maintainer != "" {
+		logger.Info(fmt.Sprintf("Filter by maintainer:%s", filter.Maintainer))
+		filters = append(filters, searchFilter{field: "maintainer.keyword", value: filter.Maintainer, exact: true})
+	}
+
+	response, err := s.search(ctx, resultCloudstackIndex, filters, filter.Max)
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to run query %v", err))
+		logger.Error(err, "Failed to run query")
 		return nil, err
 	}
 
-	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", searchResult.TookInMillis))
+	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", response.took))
+
+	results := make([]Result, len(response.sources))
+	for i, source := range response.sources {
+		if err := json.Unmarshal(source, &results[i]); err != nil {
+			return nil, &QueryError{URL: storeURL(resultCloudstackESURL), Index: resultCloudstackIndex,
+				Err: fmt.Errorf("invalid result: %w", err)}
+		}
+	}
+
+	return results, nil
+}
 
-	return searchResult, nil
+// ResultDisplayOptions controls how DisplayResult displays results.
+type ResultDisplayOptions struct {
+	// ShowMaintainer displays the MAINTAINER column
+	ShowMaintainer bool
+	// Quarantine, if set, is used to mark quarantined tests. Failures of
+	// quarantined tests are not counted.
+	Quarantine *quarantine.List
+	// ExcludeQuarantined hides quarantined tests
+	ExcludeQuarantined bool
+	// Details displays the FAILURE MESSAGE, LOCATION and ARTIFACTS columns
+	Details bool
+	// Format is the output format. JSON output contains all the fields of
+	// the displayed results, whatever the other options.
+	Format OutputFormat
 }
 
+// DisplayResult displays results matching filter and returns the number of
+// failed results displayed, quarantined tests excluded.
 func DisplayResult(ctx context.Context, logger logr.Logger,
-	run, testName string,
-	vcs, ucs, passed, failed, skipped bool,
-	maxResult int,
-) error {
-	searchResult, err := GetResults(ctx, logger, run, testName, vcs, ucs, passed, failed, skipped, maxResult)
+	filter ResultFilter, options ResultDisplayOptions,
+) (int, error) {
+	results, err := GetResults(ctx, logger, filter)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ENVIRONMENT", "RUN", "TEST", "RESULT", "DURATION"})
-	table.SetAutoWrapText(false)
-	table.SetRowLine(true)
+	header := []string{"ENVIRONMENT", "RUN", "TEST", "RESULT", "DURATION"}
+	if options.ShowMaintainer {
+		header = append(header, "MAINTAINER")
+	}
+	if options.Details {
+		header = append(header, "FAILURE MESSAGE", "LOCATION", "ARTIFACTS")
+	}
 
-	var rtyp Result
-	for _, item := range searchResult.Each(reflect.TypeOf(rtyp)) {
-		r := item.(Result)
+	failures := 0
+	rows := make([][]string, 0, len(results))
+	displayed := make([]Result, 0, len(results))
+	for _, r := range results {
+		quarantined := options.Quarantine.IsQuarantined(r.Name)
+		if quarantined && options.ExcludeQuarantined {
+			continue
+		}
+		if r.Result == "failed" && !quarantined {
+			failures++
+		}
 		name := r.Name
 		if r.Serial {
 			name = fmt.Sprintf("%s*", r.Name)
 		}
-		table.Append([]string{r.Environment, strconv.Itoa(r.Run), name,
-			r.Result, fmt.Sprintf("%f", r.DurationInMinutes)})
+		if quarantined {
+			name = fmt.Sprintf("%s [quarantined]", name)
+		}
+		row := []string{r.Environment, strconv.Itoa(r.Run), name,
+			r.Result, fmt.Sprintf("%f", r.DurationInMinutes)}
+		if options.ShowMaintainer {
+			row = append(row, r.Maintainer)
+		}
+		if options.Details {
+			row = append(row, strings.TrimSpace(r.FailureMessage), r.FailureLocation, formatArtifacts(r.Artifacts))
+		}
+		rows = append(rows, row)
+		displayed = append(displayed, r)
 	}
 
-	table.Render()
+	if err := printEntries(options.Format, header, rows, displayed); err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+// formatArtifacts returns one "name: url" line per artifact.
+func formatArtifacts(artifacts []Artifact) string {
+	lines := make([]string, len(artifacts))
+	for i := range artifacts {
+		lines[i] = fmt.Sprintf("%s: %s", artifacts[i].Name, artifacts[i].URL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PushResults stores results in bulk. Results are stored with the id returned
+// by ResultID: pushing the results of a run again replaces them.
+func PushResults(ctx context.Context, logger logr.Logger, results []Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	s, err := getStore(storeURL(resultCloudstackESURL))
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return err
+	}
+
+	if err = s.ensureIndex(ctx, resultCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to create index")
+		return err
+	}
+
+	for start := 0; start < len(results); start += bulkSize {
+		end := start + bulkSize
+		if end > len(results) {
+			end = len(results)
+		}
+
+		docs := make([]document, 0, end-start)
+		for i := start; i < end; i++ {
+			docs = append(docs, document{id: ResultID(&results[i]), source: results[i]})
+		}
+
+		if err := s.index(ctx, resultCloudstackIndex, docs); err != nil {
+			logger.Error(err, "Failed to index results")
+			return err
+		}
+	}
 
 	return nil
 }
+
+// ListResultsForRuns returns all results of the given runs in environment env,
+// most recent run first. There is no limit on the number of results.
+func ListResultsForRuns(ctx context.Context, logger logr.Logger,
+	env string, runs []int,
+) ([]Result, error) {
+	c, err := GetClient(storeURL(resultCloudstackESURL))
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return nil, err
+	}
+
+	if err = VerifyIndex(ctx, logger, c, storeURL(resultCloudstackESURL), resultCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to verify index")
+		return nil, err
+	}
+
+	runIDs := make([]interface{}, len(runs))
+	for i := range runs {
+		runIDs[i] = runs[i]
+	}
+
+	generalQ := elastic.NewBoolQuery().
+		Filter(elastic.NewMatchQuery("environment", env)).
+		Filter(elastic.NewTermsQuery("run", runIDs...))
+
+	// Results of many runs can exceed the maximum number of documents a
+	// single query returns: results are scrolled.
+	m, err := indexOfKind(ResultDocuments)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0)
+	err = scrollDocuments(ctx, c, m, generalQ, func(hit *elastic.SearchHit) error {
+		var r Result
+		if err := json.Unmarshal(hit.Source, &r); err != nil {
+			return err
+		}
+		results = append(results, r)
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "Failed to scroll results")
+		return nil, err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Run > results[j].Run })
+
+	return results, nil
+}
@@ -0,0 +1,150 @@
+package es_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// RetryPolicy controls how connecting to and querying Elasticsearch
+// is retried when the backend is unreachable.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	// Zero disables retries.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry. Every following
+	// retry doubles it.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used unless SetRetryPolicy is called.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+var retryPolicy = DefaultRetryPolicy
+
+// SetRetryPolicy overrides the policy used for all Elasticsearch requests.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy = p
+}
+
+// backoff returns how long to wait before retry number attempt (0 based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// withRetry runs op until it succeeds, fails with a non transient error or
+// retries are exhausted. It returns the number of attempts made and the
+// last error seen.
+func withRetry(ctx context.Context, op func() error) (int, error) {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || !isTransient(err) || attempt >= retryPolicy.MaxRetries {
+			return attempt + 1, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt + 1, err
+		case <-time.After(retryPolicy.backoff(attempt)):
+		}
+	}
+}
+
+// transientStatuses are the HTTP statuses of requests worth retrying.
+var transientStatuses = []int{http.StatusTooManyRequests, http.StatusBadGateway,
+	http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// isTransient returns true if err indicates Elasticsearch could not be
+// reached or is temporarily unable to serve requests.
+func isTransient(err error) bool {
+	if elastic.IsConnErr(err) || elastic.IsTimeout(err) {
+		return true
+	}
+
+	var responseErr *responseError
+	isResponseErr := errors.As(err, &responseErr)
+	for _, code := range transientStatuses {
+		if elastic.IsStatusCode(err, code) || (isResponseErr && responseErr.status == code) {
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// runQuery executes query against index retrying transient failures.
+// Errors are returned as ConnectionError or QueryError.
+func runQuery(ctx context.Context, esURL, index string,
+	query func() (*elastic.SearchResult, error),
+) (*elastic.SearchResult, error) {
+	var searchResult *elastic.SearchResult
+	err := runRequest(ctx, esURL, index, func() error {
+		var err error
+		searchResult, err = query()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return searchResult, nil
+}
+
+// runRequest executes request against index retrying transient failures.
+// Errors are returned as ConnectionError or QueryError.
+func runRequest(ctx context.Context, esURL, index string, request func() error) error {
+	attempts, err := withRetry(ctx, request)
+	if err != nil {
+		if isTransient(err) {
+			return &ConnectionError{URL: esURL, Attempts: attempts, Err: err}
+		}
+		return &QueryError{URL: esURL, Index: index, Err: err}
+	}
+
+	return nil
+}
+
+// runBulk executes bulk against index retrying transient failures. A bulk
+// request which succeeds with failed items returns a QueryError reporting
+// the first failure.
+func runBulk(ctx context.Context, esURL, index string, bulk *elastic.BulkService) error {
+	var response *elastic.BulkResponse
+	err := runRequest(ctx, esURL, index, func() error {
+		var err error
+		response, err = bulk.Do(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed := response.Failed(); len(failed) > 0 {
+		reason := "unknown error"
+		if failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		return &QueryError{URL: esURL, Index: index,
+			Err: fmt.Errorf("%d document(s) not indexed, first error: %s", len(failed), reason)}
+	}
+
+	return nil
+}
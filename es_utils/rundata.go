@@ -0,0 +1,142 @@
+package es_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrNoResults is returned by LoadRunData when the run has no results.
+var ErrNoResults = errors.New("no results found")
+
+// RunData contains all data collected for a run.
+type RunData struct {
+	// Environment is the environment the run belongs to
+	Environment string
+	// Run is the run id
+	Run int
+	// PreviousRun is the run preceding Run in the same environment.
+	// Zero if there is none.
+	PreviousRun int
+	// Results are the test results of Run
+	Results []Result
+	// PreviousResults are the test results of PreviousRun
+	PreviousResults []Result
+	// Reports are the reports of Run
+	Reports []Report
+	// UsageReports are the usage reports of Run
+	UsageReports []UsageReport
+}
+
+// LoadRunData collects results, reports and usage reports of run in
+// environment env, along with results of the previous run.
+func LoadRunData(ctx context.Context, logger logr.Logger,
+	env string, run int,
+) (*RunData, error) {
+	vcs, ucs := env == "vcs", env == "ucs"
+	runID := strconv.Itoa(run)
+
+	data := &RunData{Environment: env, Run: run}
+
+	var err error
+	data.Results, err = GetResults(ctx, logger, ResultFilter{Environment: env, Run: runID, Max: MaxQuerySize})
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Results) == 0 {
+		return nil, fmt.Errorf("%w for run %d in environment %s", ErrNoResults, run, env)
+	}
+
+	previous, found, err := PreviousRun(ctx, logger, env, run)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		data.PreviousRun = previous
+		data.PreviousResults, err = GetResults(ctx, logger,
+			ResultFilter{Environment: env, Run: strconv.Itoa(previous), Max: MaxQuerySize})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data.Reports, err = GetReports(ctx, logger, runID, "", "", "", vcs, ucs, MaxQuerySize)
+	if err != nil {
+		return nil, err
+	}
+
+	data.UsageReports, err = GetUsageReports(ctx, logger, runID, "", vcs, ucs, MaxQuerySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Counts returns the number of passed, failed and skipped tests of the run.
+func (d *RunData) Counts() (passed, failed, skipped int) {
+	for i := range d.Results {
+		switch d.Results[i].Result {
+		case "passed":
+			passed++
+		case "failed":
+			failed++
+		case "skipped":
+			skipped++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// NewFailures returns results of tests which failed in the run but did not
+// fail in the previous run. If there is no previous run, nil is returned.
+func (d *RunData) NewFailures() []Result {
+	if d.PreviousRun == 0 {
+		return nil
+	}
+
+	previouslyFailed := make(map[string]bool)
+	for i := range d.PreviousResults {
+		if d.PreviousResults[i].Result == "failed" {
+			previouslyFailed[d.PreviousResults[i].Name] = true
+		}
+	}
+
+	newFailures := make([]Result, 0)
+	for i := range d.Results {
+		if d.Results[i].Result == "failed" && !previouslyFailed[d.Results[i].Name] {
+			newFailures = append(newFailures, d.Results[i])
+		}
+	}
+
+	return newFailures
+}
+
+// ExcludeTests removes, from Results and PreviousResults, results of tests
+// for which excluded returns true. It returns the names of excluded tests
+// which failed in Run.
+func (d *RunData) ExcludeTests(excluded func(test string) bool) []string {
+	failed := make([]string, 0)
+	results := make([]Result, 0, len(d.Results))
+	for i := range d.Results {
+		if !excluded(d.Results[i].Name) {
+			results = append(results, d.Results[i])
+		} else if d.Results[i].Result == "failed" {
+			failed = append(failed, d.Results[i].Name)
+		}
+	}
+	d.Results = results
+
+	previousResults := make([]Result, 0, len(d.PreviousResults))
+	for i := range d.PreviousResults {
+		if !excluded(d.PreviousResults[i].Name) {
+			previousResults = append(previousResults, d.PreviousResults[i])
+		}
+	}
+	d.PreviousResults = previousResults
+
+	return failed
+}
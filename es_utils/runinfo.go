@@ -0,0 +1,242 @@
+package es_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+const runInfoCloudstackESURL = "http://172.31.165.56:9200"
+
+const runInfoCloudstackIndex = "cs_e2e_runs"
+
+// RunInfo contains metadata about a run: what was tested and how the run
+// was started.
+type RunInfo struct {
+	// Run is the sanity run id
+	Run int `json:"run"`
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string `json:"environment"`
+	// GitSHA is the commit tested
+	GitSHA string `json:"gitSHA,omitempty"`
+	// Branch is the branch GitSHA belongs to
+	Branch string `json:"branch,omitempty"`
+	// ComponentVersions contains the version of each deployed component
+	ComponentVersions map[string]string `json:"componentVersions,omitempty"`
+	// KubernetesVersion is the Kubernetes version of the cluster under test
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// CIJobURL is the URL of the CI job which ran the tests
+	CIJobURL string `json:"ciJobURL,omitempty"`
+	// StartTime is the time run started
+	StartTime time.Time `json:"startTime"`
+	// EndTime is the time run ended
+	EndTime time.Time `json:"endTime"`
+	// Trigger is what started the run, i.e. nightly, pull-request, manual
+	Trigger string `json:"trigger,omitempty"`
+}
+
+// runInfoID returns the document id of the RunInfo of a run, so that pushing
+// the RunInfo of a run again replaces it.
+func runInfoID(env string, run int) string {
+	return fmt.Sprintf("%s-%d", env, run)
+}
+
+// PushRunInfo stores info, replacing any RunInfo previously stored for the
+// same run.
+func PushRunInfo(ctx context.Context, logger logr.Logger, info *RunInfo) error {
+	c, err := GetClient(storeURL(runInfoCloudstackESURL))
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return err
+	}
+
+	if err = ensureIndex(ctx, c, storeURL(runInfoCloudstackESURL), runInfoCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to create index")
+		return err
+	}
+
+	err = runRequest(ctx, storeURL(runInfoCloudstackESURL), runInfoCloudstackIndex, func() error {
+		_, err := c.Index().Index(runInfoCloudstackIndex).
+			Id(runInfoID(info.Environment, info.Run)).
+			BodyJson(info).
+			Do(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Error(err, "Failed to index run info")
+		return err
+	}
+
+	return nil
+}
+
+// ListRunInfos returns the RunInfo of each of runs of environment env, by run.
+// Runs without metadata are not in the returned map. No metadata at all is
+// returned, with no error, if the run info index does not exist yet.
+func ListRunInfos(ctx context.Context, logger logr.Logger,
+	env string, runs []int,
+) (map[int]RunInfo, error) {
+	infos := make(map[int]RunInfo)
+	if len(runs) == 0 {
+		return infos, nil
+	}
+
+	c, err := GetClient(storeURL(runInfoCloudstackESURL))
+	if err != nil {
+		logger.Error(err, "Failed to get client")
+		return nil, err
+	}
+
+	if err = VerifyIndex(ctx, logger, c, storeURL(runInfoCloudstackESURL), runInfoCloudstackIndex); err != nil {
+		var notFound *IndexNotFoundError
+		if errors.As(err, &notFound) {
+			return infos, nil
+		}
+		logger.Error(err, "Failed to verify index")
+		return nil, err
+	}
+
+	runIDs := make([]interface{}, len(runs))
+	for i := range runs {
+		runIDs[i] = runs[i]
+	}
+
+	generalQ := elastic.NewBoolQuery().
+		Filter(elastic.NewMatchQuery("environment", env)).
+		Filter(elastic.NewTermsQuery("run", runIDs...))
+
+	searchResult, err := runQuery(ctx, storeURL(runInfoCloudstackESURL), runInfoCloudstackIndex, func() (*elastic.SearchResult, error) {
+		return c.Search().Index(runInfoCloudstackIndex).Query(generalQ).Size(len(runs)).Do(ctx)
+	})
+	if err != nil {
+		logger.Error(err, "Failed to run query")
+		return nil, err
+	}
+
+	for _, hit := range searchResult.Hits.Hits {
+		var info RunInfo
+		if err := json.Unmarshal(hit.Source, &info); err != nil {
+			return nil, err
+		}
+		infos[info.Run] = info
+	}
+
+	return infos, nil
+}
+
+// GetRunInfo returns the RunInfo of run in environment env.
+// Second returned value is false if no metadata was pushed for the run.
+func GetRunInfo(ctx context.Context, logger logr.Logger,
+	env string, run int,
+) (*RunInfo, bool, error) {
+	infos, err := ListRunInfos(ctx, logger, env, []int{run})
+	if err != nil {
+		return nil, false, err
+	}
+
+	info, ok := infos[run]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &info, true, nil
+}
+
+// DisplayRunInfo displays the metadata of run in the selected environments
+// (both if neither vcs nor ucs is set).
+func DisplayRunInfo(ctx context.Context, logger logr.Logger,
+	run int,
+	vcs, ucs bool,
+) error {
+	found := false
+	for _, env := range []string{"vcs", "ucs"} {
+		if (env == "vcs" && ucs) || (env == "ucs" && vcs) {
+			continue
+		}
+
+		info, ok, err := GetRunInfo(ctx, logger, env, run)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		found = true
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"FIELD", "VALUE"})
+		table.SetAutoWrapText(false)
+		table.SetRowLine(true)
+
+		table.Append([]string{"Environment", info.Environment})
+		table.Append([]string{"Run", strconv.Itoa(info.Run)})
+		table.Append([]string{"Git SHA", info.GitSHA})
+		table.Append([]string{"Branch", info.Branch})
+		table.Append([]string{"Kubernetes version", info.KubernetesVersion})
+		table.Append([]string{"Component versions", info.componentVersions("\n")})
+		table.Append([]string{"Trigger", info.Trigger})
+		table.Append([]string{"Start time", formatTime(info.StartTime)})
+		table.Append([]string{"End time", formatTime(info.EndTime)})
+		table.Append([]string{"Duration", info.duration()})
+		table.Append([]string{"CI job", info.CIJobURL})
+
+		table.Render()
+	}
+
+	if !found {
+		fmt.Printf("No metadata was pushed for run %d\n", run)
+	}
+
+	return nil
+}
+
+// componentVersions returns component versions, sorted by component, as
+// name=version separated by sep.
+func (i *RunInfo) componentVersions(sep string) string {
+	names := make([]string, 0, len(i.ComponentVersions))
+	for name := range i.ComponentVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	versions := make([]string, len(names))
+	for j, name := range names {
+		versions[j] = name + "=" + i.ComponentVersions[name]
+	}
+
+	return strings.Join(versions, sep)
+}
+
+// shortSHA returns the abbreviated commit tested.
+func (i *RunInfo) shortSHA() string {
+	const shortSHALength = 12
+	if len(i.GitSHA) > shortSHALength {
+		return i.GitSHA[:shortSHALength]
+	}
+	return i.GitSHA
+}
+
+// duration returns how long the run took, empty if unknown.
+func (i *RunInfo) duration() string {
+	if i.StartTime.IsZero() || i.EndTime.IsZero() {
+		return ""
+	}
+	return i.EndTime.Sub(i.StartTime).Round(time.Minute).String()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -2,31 +2,30 @@ package es_utils
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	"github.com/olekukonko/tablewriter"
-	elastic "github.com/olivere/elastic/v7"
 )
 
 func DisplayRuns(ctx context.Context, logger logr.Logger,
 	vcs, ucs bool,
 	maxResult int,
 ) error {
-	c, err := GetClient(resultCloudstackESURL)
+	s, err := getStore(storeURL(resultCloudstackESURL))
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to get client: %v", err))
+		logger.Error(err, "Failed to get client")
 		return err
 	}
 
-	if err = VerifyIndex(ctx, c, resultCloudstackIndex); err != nil {
-		logger.Info(fmt.Sprintf("Failed to verify index %v", err))
+	if err = s.verifyIndex(ctx, logger, resultCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to verify index")
 		return err
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ENVIRONMENT", "RUN"})
+	table.SetHeader([]string{"ENVIRONMENT", "RUN", "GIT SHA", "BRANCH", "KUBERNETES", "TRIGGER", "START TIME", "DURATION", "CI JOB"})
 	table.SetAutoWrapText(false)
 	table.SetRowLine(true)
 
@@ -46,46 +45,121 @@ func DisplayRuns(ctx context.Context, logger logr.Logger,
 	return nil
 }
 
+// GetAvailableRuns returns at most maxResult runs of environment match,
+// most recent first.
 func GetAvailableRuns(ctx context.Context,
-	match string, maxResult int, logger logr.Logger) (*elastic.AggregationBucketKeyItems, error) {
-	c, err := GetClient(resultCloudstackESURL)
+	match string, maxResult int, logger logr.Logger) ([]int, error) {
+	s, err := getStore(storeURL(resultCloudstackESURL))
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to get client: %v", err))
+		logger.Error(err, "Failed to get client")
 		return nil, err
 	}
 
-	field := "run"
-	termAggr := elastic.NewTermsAggregation().Field(field).Size(maxResult).Order("_key", false)
-	searchResult, err := c.Search().Index(resultCloudstackIndex).
-		Query(elastic.NewMatchQuery("environment", match)).
-		Aggregation(field, termAggr).
-		Do(ctx)
+	runs, err := s.runs(ctx, resultCloudstackIndex, match, maxResult)
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to run query %v", err))
+		logger.Error(err, "Failed to run query")
 		return nil, err
 	}
 
-	logger.Info(fmt.Sprintf("total hits: %v\n\n", searchResult.Hits.TotalHits))
-
-	b, found := searchResult.Aggregations.Terms(field)
-	if !found {
-		logger.Info("Not found")
-		return nil, fmt.Errorf("failed to get term aggregation results")
-	}
-
-	return b, nil
+	return runs, nil
 }
 
 func aggregationQueryForRun(ctx context.Context,
 	match string, maxResult int, table *tablewriter.Table,
 	logger logr.Logger) error {
-	b, err := GetAvailableRuns(ctx, match, maxResult, logger)
+	runs, err := ListRuns(ctx, logger, match == "vcs", match == "ucs", maxResult)
 	if err != nil {
 		return err
 	}
 
-	for _, bucket := range b.Buckets {
-		table.Append([]string{match, bucket.KeyNumber.String()})
+	for _, r := range runs {
+		row := []string{match, strconv.Itoa(r.Run)}
+		if i := r.Info; i != nil {
+			row = append(row, i.shortSHA(), i.Branch, i.KubernetesVersion, i.Trigger,
+				formatTime(i.StartTime), i.duration(), i.CIJobURL)
+		} else {
+			row = append(row, "", "", "", "", "", "", "")
+		}
+		table.Append(row)
 	}
 	return nil
 }
+
+// PreviousRun returns the most recent run, in environment env, preceding run.
+// Second returned value is false if there is no such run.
+func PreviousRun(ctx context.Context, logger logr.Logger,
+	env string, run int) (int, bool, error) {
+	runs, err := GetAvailableRuns(ctx, env, MaxQuerySize, logger)
+	if err != nil {
+		return 0, false, err
+	}
+
+	previous, found := 0, false
+	for _, id := range runs {
+		if id < run && id > previous {
+			previous, found = id, true
+		}
+	}
+
+	return previous, found, nil
+}
+
+// RunEntry identifies a run for which results were collected.
+type RunEntry struct {
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string `json:"environment"`
+	// Run is the sanity run id
+	Run int `json:"run"`
+	// Info contains the run metadata, if any was pushed
+	Info *RunInfo `json:"info,omitempty"`
+}
+
+// ListRuns returns available runs, most recent first, with their metadata,
+// for the selected environments (both if neither vcs nor ucs is set).
+func ListRuns(ctx context.Context, logger logr.Logger,
+	vcs, ucs bool,
+	maxResult int,
+) ([]RunEntry, error) {
+	runs := make([]RunEntry, 0)
+	for _, env := range []string{"vcs", "ucs"} {
+		if (env == "vcs" && ucs) || (env == "ucs" && vcs) {
+			continue
+		}
+
+		ids, err := GetAvailableRuns(ctx, env, maxResult, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		infos, err := ListRunInfos(ctx, logger, env, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			entry := RunEntry{Environment: env, Run: id}
+			if info, ok := infos[id]; ok {
+				entry.Info = &info
+			}
+			runs = append(runs, entry)
+		}
+	}
+
+	return runs, nil
+}
+
+// LatestRun returns the most recent run in environment env.
+// Second returned value is false if there is no run.
+func LatestRun(ctx context.Context, logger logr.Logger,
+	env string) (int, bool, error) {
+	runs, err := GetAvailableRuns(ctx, env, 1, logger)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(runs) == 0 {
+		return 0, false, nil
+	}
+
+	return runs[0], true, nil
+}
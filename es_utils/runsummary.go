@@ -0,0 +1,186 @@
+package es_utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// testGroup summarizes a set of tests of a run.
+type testGroup struct {
+	tests                   int
+	passed, failed, skipped int
+	// total is the sum of test durations
+	total time.Duration
+	// start and end are the time first test started and last test ended
+	start, end time.Time
+}
+
+func (g *testGroup) add(r *Result) {
+	g.tests++
+	switch r.Result {
+	case "passed":
+		g.passed++
+	case "failed":
+		g.failed++
+	case "skipped":
+		g.skipped++
+	}
+
+	g.total += r.DurationInSecond
+
+	if r.StartTime.IsZero() {
+		return
+	}
+	if g.start.IsZero() || r.StartTime.Before(g.start) {
+		g.start = r.StartTime
+	}
+	if end := r.StartTime.Add(r.DurationInSecond); end.After(g.end) {
+		g.end = end
+	}
+}
+
+// wallClock returns the time elapsed between the start of the first test and
+// the end of the last one, empty if unknown.
+func (g *testGroup) wallClock() string {
+	if g.start.IsZero() {
+		return ""
+	}
+	return formatDuration(g.end.Sub(g.start))
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// DisplayRunSummary displays, in one screen, counts and durations of the tests
+// of a run, with serial vs parallel breakdown, the top slowest tests, the
+// reports and the top memory and CPU consumers of the run.
+// info, if set, contains the run metadata.
+func DisplayRunSummary(data *RunData, info *RunInfo, top int) {
+	fmt.Printf("Run %d (%s)\n", data.Run, data.Environment)
+	if info != nil {
+		fmt.Printf("Commit %s on %s, Kubernetes %s, triggered by %s\n",
+			valueOrUnknown(info.shortSHA()), valueOrUnknown(info.Branch),
+			valueOrUnknown(info.KubernetesVersion), valueOrUnknown(info.Trigger))
+		if info.CIJobURL != "" {
+			fmt.Printf("CI job %s\n", info.CIJobURL)
+		}
+	}
+	fmt.Println()
+
+	var all, serial, parallel testGroup
+	for i := range data.Results {
+		r := &data.Results[i]
+		all.add(r)
+		if r.Serial {
+			serial.add(r)
+		} else {
+			parallel.add(r)
+		}
+	}
+
+	table := newSummaryTable([]string{"TESTS", "TOTAL", "PASSED", "FAILED", "SKIPPED", "TOTAL DURATION", "WALL-CLOCK"})
+	for _, g := range []struct {
+		name  string
+		group *testGroup
+	}{{"all", &all}, {"serial", &serial}, {"parallel", &parallel}} {
+		table.Append([]string{g.name, strconv.Itoa(g.group.tests),
+			strconv.Itoa(g.group.passed), strconv.Itoa(g.group.failed), strconv.Itoa(g.group.skipped),
+			formatDuration(g.group.total), g.group.wallClock()})
+	}
+	table.Render()
+
+	slowest := make([]Result, len(data.Results))
+	copy(slowest, data.Results)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].DurationInSecond > slowest[j].DurationInSecond
+	})
+	if len(slowest) > top {
+		slowest = slowest[:top]
+	}
+
+	fmt.Printf("\nSlowest tests\n")
+	table = newSummaryTable([]string{"TEST", "RESULT", "SERIAL", "DURATION"})
+	for i := range slowest {
+		r := &slowest[i]
+		table.Append([]string{r.Name, r.Result, strconv.FormatBool(r.Serial), formatDuration(r.DurationInSecond)})
+	}
+	table.Render()
+
+	reports := make([]Report, len(data.Reports))
+	copy(reports, data.Reports)
+	sort.SliceStable(reports, func(i, j int) bool {
+		if reports[i].Type != reports[j].Type {
+			return reports[i].Type < reports[j].Type
+		}
+		if reports[i].SubType != reports[j].SubType {
+			return reports[i].SubType < reports[j].SubType
+		}
+		return reports[i].Name < reports[j].Name
+	})
+
+	fmt.Printf("\nReports\n")
+	table = newSummaryTable([]string{"TYPE", "SUBTYPE", "NAME", "DURATION (MINUTES)"})
+	for i := range reports {
+		r := &reports[i]
+		table.Append([]string{r.Type, r.SubType, r.Name, fmt.Sprintf("%.2f", r.DurationInMinutes)})
+	}
+	table.Render()
+
+	usage := make([]UsageReport, len(data.UsageReports))
+	copy(usage, data.UsageReports)
+
+	fmt.Printf("\nTop memory consumers\n")
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Memory > usage[j].Memory })
+	table = newSummaryTable([]string{"POD", "MEMORY", "LIMIT", "% OF LIMIT"})
+	for i := 0; i < len(usage) && i < top; i++ {
+		u := &usage[i]
+		table.Append([]string{u.Name, fmt.Sprintf("%dKi", u.Memory), limit(u.MemoryLimit, "Ki"),
+			percentOfLimit(u.Memory, u.MemoryLimit)})
+	}
+	table.Render()
+
+	fmt.Printf("\nTop CPU consumers\n")
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].CPU > usage[j].CPU })
+	table = newSummaryTable([]string{"POD", "CPU", "LIMIT", "% OF LIMIT"})
+	for i := 0; i < len(usage) && i < top; i++ {
+		u := &usage[i]
+		table.Append([]string{u.Name, fmt.Sprintf("%dm", u.CPU), limit(u.CPULimit, "m"),
+			percentOfLimit(u.CPU, u.CPULimit)})
+	}
+	table.Render()
+}
+
+func newSummaryTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	return table
+}
+
+func limit(value int64, unit string) string {
+	if value == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d%s", value, unit)
+}
+
+func percentOfLimit(used, limit int64) string {
+	if limit == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", float64(used)*100/float64(limit))
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,486 @@
+package es_utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	elastic "github.com/olivere/elastic/v7"
+)
+
+// SchemaVersion is the version of the index mappings created by InitIndices.
+// It must be increased every time a mapping changes.
+const SchemaVersion = 1
+
+// schemaVersionKey is the key, in the _meta of an index mapping, the schema
+// version is stored under.
+const schemaVersionKey = "schema_version"
+
+// managedIndex is an index whose mapping is created by InitIndices.
+type managedIndex struct {
+	// kind is the kind of documents stored in index
+	kind string
+	// defaultURL is the Elasticsearch URL index is stored at, unless the
+	// store sets one
+	defaultURL string
+	index      string
+	properties map[string]interface{}
+	// timeField is the field recording when a document was produced
+	timeField string
+}
+
+// esURL returns the Elasticsearch URL index m is stored at.
+func (m *managedIndex) esURL() string {
+	return storeURL(m.defaultURL)
+}
+
+// managedIndices lists the indices used by e2e_result with their mappings.
+var managedIndices = []managedIndex{
+	{kind: ResultDocuments, defaultURL: resultCloudstackESURL, index: resultCloudstackIndex,
+		properties: resultProperties, timeField: "startTime"},
+	{kind: ReportDocuments, defaultURL: reportCloudstackESURL, index: reportCloudstackIndex,
+		properties: reportProperties, timeField: "createdTime"},
+	{kind: UsageDocuments, defaultURL: usageCloudstackESURL, index: usageCloudstackIndex,
+		properties: usageProperties, timeField: "createdTime"},
+	{kind: RunInfoDocuments, defaultURL: runInfoCloudstackESURL, index: runInfoCloudstackIndex,
+		properties: runInfoProperties, timeField: "startTime"},
+}
+
+// Field mappings. They are the ones dynamic mapping creates, so that indices
+// created before explicit mappings existed are up to date once the schema
+// version is recorded: strings are analyzed, with a keyword subfield for
+// exact matches (name.keyword), integers are longs and decimals are floats.
+func textField() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+		},
+	}
+}
+
+func field(fieldType string) map[string]interface{} {
+	return map[string]interface{}{"type": fieldType}
+}
+
+// resultProperties maps Result.
+var resultProperties = map[string]interface{}{
+	"name":              textField(),
+	"description":       textField(),
+	"maintainer":        textField(),
+	"durationInMinutes": field("float"),
+	"durationInSeconds": field("long"),
+	"result":            textField(),
+	"environment":       textField(),
+	"run":               field("long"),
+	"startTime":         field("date"),
+	"serial":            field("boolean"),
+	"failureMessage":    textField(),
+	"failureLocation":   textField(),
+	"artifacts": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name": textField(),
+			"url":  textField(),
+		},
+	},
+}
+
+// reportProperties maps Report.
+var reportProperties = map[string]interface{}{
+	"type":              textField(),
+	"name":              textField(),
+	"subType":           textField(),
+	"durationInMinutes": field("float"),
+	"environment":       textField(),
+	"run":               field("long"),
+	"createdTime":       field("date"),
+}
+
+// usageProperties maps UsageReport.
+var usageProperties = map[string]interface{}{
+	"name":        textField(),
+	"memory":      field("long"),
+	"cpu":         field("long"),
+	"memoryLimit": field("long"),
+	"cpuLimit":    field("long"),
+	"environment": textField(),
+	"run":         field("long"),
+	"createdTime": field("date"),
+}
+
+// runInfoProperties maps RunInfo. Component names are not known in advance:
+// component versions are mapped dynamically.
+var runInfoProperties = map[string]interface{}{
+	"run":               field("long"),
+	"environment":       textField(),
+	"gitSHA":            textField(),
+	"branch":            textField(),
+	"componentVersions": map[string]interface{}{"type": "object", "dynamic": true},
+	"kubernetesVersion": textField(),
+	"ciJobURL":          textField(),
+	"startTime":         field("date"),
+	"endTime":           field("date"),
+	"trigger":           textField(),
+}
+
+// mapping returns the mapping of index m, schema version included.
+func (m *managedIndex) mapping() map[string]interface{} {
+	return map[string]interface{}{
+		"_meta":      map[string]interface{}{schemaVersionKey: SchemaVersion},
+		"properties": m.properties,
+	}
+}
+
+// IndexAction is what InitIndices does to an index.
+type IndexAction string
+
+const (
+	// IndexCreated is reported for an index which did not exist
+	IndexCreated IndexAction = "created"
+	// IndexUpdated is reported for an index with an older schema version,
+	// whose mapping is updated in place
+	IndexUpdated IndexAction = "updated"
+	// IndexUpToDate is reported for an index with the current schema version
+	IndexUpToDate IndexAction = "up to date"
+	// IndexNeedsReindex is reported for an index with fields whose type
+	// differs from the current mapping, which cannot be updated in place
+	IndexNeedsReindex IndexAction = "reindex needed"
+	// IndexFailed is reported for an index which could not be initialized
+	IndexFailed IndexAction = "failed"
+)
+
+// IndexSchema reports the schema of an index.
+type IndexSchema struct {
+	// URL is the Elasticsearch URL index is stored at
+	URL string
+	// Index is the index name
+	Index string
+	// Exists is false if index did not exist
+	Exists bool
+	// Version is the schema version index had. Zero if index did not exist or
+	// was created by dynamic mapping.
+	Version int
+	// Action is what InitIndices did or, in dry run, would do
+	Action IndexAction
+	// Mismatches lists the fields whose type differs from the current
+	// mapping, as "field: type (expected type)"
+	Mismatches []string
+}
+
+// SchemaVersionError is returned when an index was created by a more recent
+// version of e2e_result.
+type SchemaVersionError struct {
+	// URL is the Elasticsearch URL that was queried
+	URL string
+	// Index is the index with an unsupported schema
+	Index string
+	// Version is the schema version of the index
+	Version int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("index %q at %s has schema version %d, more recent than version %d supported "+
+		"by this e2e_result. Upgrade e2e_result", e.Index, e.URL, e.Version, SchemaVersion)
+}
+
+// InitIndices creates the indices which do not exist, with explicit mappings,
+// and updates the mapping of indices with an older schema version. Fields
+// whose type differs from the one of the current mapping cannot be updated
+// in place: such indices are reported and must be reindexed. Failing to
+// initialize an index does not prevent initializing the next ones: the
+// first error is returned along with the schemas of all indices. If dryRun
+// is set, nothing is changed and the returned schemas report what would be
+// done.
+func InitIndices(ctx context.Context, logger logr.Logger, dryRun bool) ([]IndexSchema, error) {
+	schemas := make([]IndexSchema, 0, len(managedIndices))
+	var firstErr error
+	for i := range managedIndices {
+		m := &managedIndices[i]
+		schema, err := initIndex(ctx, m, dryRun)
+		if err != nil {
+			logger.Error(err, "Failed to initialize index", "index", m.index)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, firstErr
+}
+
+// initIndex creates index m or updates its mapping.
+func initIndex(ctx context.Context, m *managedIndex, dryRun bool) (IndexSchema, error) {
+	schema := IndexSchema{URL: m.esURL(), Index: m.index, Action: IndexFailed}
+
+	c, err := GetClient(m.esURL())
+	if err != nil {
+		return schema, err
+	}
+
+	mappings, err := getMapping(ctx, c, m.esURL(), m.index)
+	schema.Version = schemaVersion(mappings)
+	var notFound *IndexNotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		schema.Action = IndexCreated
+	case err != nil:
+		return schema, err
+	case schema.Version > SchemaVersion:
+		schema.Exists = true
+		return schema, &SchemaVersionError{URL: m.esURL(), Index: m.index, Version: schema.Version}
+	case schema.Version == SchemaVersion:
+		schema.Exists, schema.Action = true, IndexUpToDate
+		return schema, nil
+	default:
+		schema.Exists, schema.Action = true, IndexUpdated
+		schema.Mismatches = mismatchedFields(mappings, m.properties)
+	}
+
+	if len(schema.Mismatches) > 0 {
+		schema.Action = IndexNeedsReindex
+		return schema, fmt.Errorf("index %q at %s cannot be updated in place, fields have a different type: %s",
+			m.index, m.esURL(), strings.Join(schema.Mismatches, ", "))
+	}
+
+	if dryRun {
+		return schema, nil
+	}
+
+	if schema.Action == IndexCreated {
+		err = createIndex(ctx, c, m)
+	} else {
+		// Fields already mapped are left as they are: only missing fields
+		// are added, along with the schema version.
+		mapping := m.mapping()
+		mapping["properties"] = missingFields(mappings, m.properties)
+		err = runRequest(ctx, m.esURL(), m.index, func() error {
+			_, err := c.PutMapping().Index(m.index).BodyJson(mapping).Do(ctx)
+			return err
+		})
+	}
+	if err != nil {
+		schema.Action = IndexFailed
+	}
+
+	return schema, err
+}
+
+// mismatchedFields returns, sorted, the fields of mappings whose type differs
+// from the one in properties, formatted as "field: type (expected type)".
+// Numbers are not reported when mapped as a different numeric type, which
+// dynamic mapping does when the first document indexed has an integer
+// value. Fields mapped in only one of them are not reported: missing fields
+// are added by updating the mapping.
+func mismatchedFields(mappings, properties map[string]interface{}) []string {
+	existing, _ := mappings["properties"].(map[string]interface{})
+	mismatches := make([]string, 0)
+	for name, expected := range properties {
+		current, ok := existing[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		currentType, expectedType := fieldType(current), fieldType(expected.(map[string]interface{}))
+		if currentType != expectedType && !(numericTypes[currentType] && numericTypes[expectedType]) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s (expected %s)", name, currentType, expectedType))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// numericTypes are the Elasticsearch numeric field types.
+var numericTypes = map[string]bool{
+	"long": true, "integer": true, "short": true, "byte": true, "double": true,
+	"float": true, "half_float": true, "scaled_float": true, "unsigned_long": true,
+}
+
+// missingFields returns the fields of properties not mapped in mappings.
+func missingFields(mappings, properties map[string]interface{}) map[string]interface{} {
+	existing, _ := mappings["properties"].(map[string]interface{})
+	missing := make(map[string]interface{})
+	for name, expected := range properties {
+		if _, ok := existing[name]; !ok {
+			missing[name] = expected
+		}
+	}
+	return missing
+}
+
+// fieldType returns the type of a field mapping. Fields with no type are
+// objects.
+func fieldType(f map[string]interface{}) string {
+	if t, ok := f["type"].(string); ok {
+		return t
+	}
+	return "object"
+}
+
+// createIndex creates index m with its mapping.
+func createIndex(ctx context.Context, c *elastic.Client, m *managedIndex) error {
+	return runRequest(ctx, m.esURL(), m.index, func() error {
+		_, err := c.CreateIndex(m.index).BodyJson(map[string]interface{}{"mappings": m.mapping()}).Do(ctx)
+		return err
+	})
+}
+
+// ensureIndex creates index, with its mapping, if it does not exist yet, so
+// that pushing documents to a new cluster does not rely on dynamic mapping.
+func ensureIndex(ctx context.Context, c *elastic.Client, esURL, index string) error {
+	var exists bool
+	err := runRequest(ctx, esURL, index, func() error {
+		var err error
+		exists, err = c.IndexExists(index).Do(ctx)
+		return err
+	})
+	if err != nil || exists {
+		return err
+	}
+
+	m := managedIndexNamed(index)
+	if m == nil {
+		return nil
+	}
+	err = createIndex(ctx, c, m)
+	// Index might have been created concurrently.
+	if isAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// managedIndexNamed returns the managed index named index, nil if none.
+func managedIndexNamed(index string) *managedIndex {
+	for i := range managedIndices {
+		if managedIndices[i].index == index {
+			return &managedIndices[i]
+		}
+	}
+	return nil
+}
+
+// isAlreadyExists returns true if err reports the index to create exists.
+func isAlreadyExists(err error) bool {
+	var e *elastic.Error
+	return errors.As(err, &e) && e.Details != nil && e.Details.Type == "resource_already_exists_exception"
+}
+
+// getMapping returns the mapping of index. If index does not exist an
+// IndexNotFoundError is returned.
+func getMapping(ctx context.Context, c *elastic.Client, esURL, index string) (map[string]interface{}, error) {
+	// GetMapping service requests the deprecated typed endpoint.
+	var response map[string]interface{}
+	err := runRequest(ctx, esURL, index, func() error {
+		res, err := c.PerformRequest(ctx, elastic.PerformRequestOptions{
+			Method: "GET",
+			Path:   fmt.Sprintf("/%s/_mapping", url.PathEscape(index)),
+		})
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(res.Body, &response)
+	})
+	if err != nil {
+		var queryErr *QueryError
+		if errors.As(err, &queryErr) && elastic.IsNotFound(queryErr.Err) {
+			return nil, &IndexNotFoundError{URL: esURL, Index: index}
+		}
+		return nil, err
+	}
+
+	// Response is {"<index>": {"mappings": {"_meta": {...}, "properties": {...}}}}
+	indexMapping, _ := response[index].(map[string]interface{})
+	mappings, _ := indexMapping["mappings"].(map[string]interface{})
+	return mappings, nil
+}
+
+// schemaVersion returns the schema version stored in mappings. Zero is
+// returned for indices created by dynamic mapping.
+func schemaVersion(mappings map[string]interface{}) int {
+	meta, _ := mappings["_meta"].(map[string]interface{})
+	switch v := meta[schemaVersionKey].(type) {
+	case float64:
+		return int(v)
+	case string:
+		version, _ := strconv.Atoi(v)
+		return version
+	default:
+		return 0
+	}
+}
+
+// checkedSchemas records indices whose schema version was checked, so that
+// it is checked only once per index.
+var checkedSchemas sync.Map
+
+// checkSchemaVersion verifies index was not created by a more recent version
+// of e2e_result, in which case a SchemaVersionError is returned. An outdated
+// schema only logs a warning: queries keep working on dynamic mappings.
+func checkSchemaVersion(ctx context.Context, logger logr.Logger, c *elastic.Client, esURL, index string) error {
+	return checkMappingVersion(logger, esURL, index, func() (map[string]interface{}, error) {
+		return getMapping(ctx, c, esURL, index)
+	})
+}
+
+// checkMappingVersion is checkSchemaVersion with the mapping of index
+// returned by getMapping.
+func checkMappingVersion(logger logr.Logger, esURL, index string,
+	getMapping func() (map[string]interface{}, error),
+) error {
+	if _, checked := checkedSchemas.Load(index); checked {
+		return nil
+	}
+
+	mappings, err := getMapping()
+	if err != nil {
+		return err
+	}
+	version := schemaVersion(mappings)
+	if version > SchemaVersion {
+		return &SchemaVersionError{URL: esURL, Index: index, Version: version}
+	}
+	if version < SchemaVersion {
+		logger.Info("Index schema is outdated, run 'e2e_result admin init' to update it",
+			"index", index, "version", version, "current version", SchemaVersion)
+	}
+
+	checkedSchemas.Store(index, true)
+	return nil
+}
+
+// DisplayIndexSchemas displays schema version of indices and what was done
+// to them.
+func DisplayIndexSchemas(schemas []IndexSchema, dryRun bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	action := "ACTION"
+	if dryRun {
+		action = "ACTION (DRY RUN)"
+	}
+	table.SetHeader([]string{"URL", "INDEX", "SCHEMA VERSION", action, "MISMATCHED FIELDS"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for i := range schemas {
+		s := &schemas[i]
+		version := "-"
+		if s.Exists {
+			version = strconv.Itoa(s.Version)
+			if s.Version == 0 {
+				version = "none (dynamic mapping)"
+			}
+		}
+		table.Append([]string{s.URL, s.Index, version, string(s.Action), strings.Join(s.Mismatches, "\n")})
+	}
+
+	table.Render()
+	fmt.Printf("Current schema version is %d\n", SchemaVersion)
+}
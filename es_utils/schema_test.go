@@ -0,0 +1,52 @@
+package es_utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dynamicResultMapping is the mapping Elasticsearch dynamic mapping creates
+// for results.
+var dynamicResultMapping = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"name":              textField(),
+		"result":            textField(),
+		"environment":       textField(),
+		"run":               field("long"),
+		"durationInMinutes": field("long"),
+		"startTime":         field("date"),
+		"serial":            field("boolean"),
+		"artifacts": map[string]interface{}{
+			"properties": map[string]interface{}{"name": textField(), "url": textField()},
+		},
+	},
+}
+
+func TestMismatchedFields(t *testing.T) {
+	if got := mismatchedFields(dynamicResultMapping, resultProperties); len(got) != 0 {
+		t.Errorf("dynamic mapping of results has mismatched fields %v", got)
+	}
+
+	keywordMapping := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"result": field("keyword"),
+			"run":    field("integer"),
+		},
+	}
+	want := []string{"result: keyword (expected text)"}
+	if got := mismatchedFields(keywordMapping, resultProperties); !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatchedFields() = %v, want %v", got, want)
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	missing := missingFields(dynamicResultMapping, resultProperties)
+	for _, name := range []string{"description", "maintainer", "durationInSeconds", "failureMessage", "failureLocation"} {
+		if _, ok := missing[name]; !ok {
+			t.Errorf("%s is not reported missing", name)
+		}
+	}
+	if len(missing) != 5 {
+		t.Errorf("missingFields() = %v, want 5 fields", missing)
+	}
+}
@@ -0,0 +1,324 @@
+package es_utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	elasticsearch8 "github.com/elastic/go-elasticsearch/v8"
+	elastic "github.com/olivere/elastic/v7"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Backend is the search engine e2e data are stored in.
+//
+// For Elasticsearch 8 and OpenSearch, the searches of GetResults,
+// GetReports, GetUsageReports and GetAvailableRuns, and the documents
+// indexed by PushResults, are sent and parsed with the API of the official
+// client of the backend (see documentStore). All other operations are still
+// built, and their responses parsed, by the Elasticsearch 7 client, in
+// compatibility mode: the official client only sends them, so that its
+// authentication and TLS options are used.
+type Backend string
+
+const (
+	// Elasticsearch7 is Elasticsearch 7, the default backend
+	Elasticsearch7 Backend = "elasticsearch7"
+	// Elasticsearch8 is Elasticsearch 8, reached with the go-elasticsearch
+	// client. Requests in compatibility mode ask Elasticsearch to apply the
+	// REST API compatibility with version 7
+	Elasticsearch8 Backend = "elasticsearch8"
+	// OpenSearch is OpenSearch, reached with the opensearch-go client.
+	// Requests in compatibility mode rely on the APIs OpenSearch kept
+	// compatible with Elasticsearch 7
+	OpenSearch Backend = "opensearch"
+)
+
+// compatibleWith7 are the media types asking Elasticsearch 8 to accept and
+// return Elasticsearch 7 requests and responses.
+var compatibleWith7 = map[string]string{
+	"application/json":     "application/vnd.elasticsearch+json; compatible-with=7",
+	"application/x-ndjson": "application/vnd.elasticsearch+x-ndjson; compatible-with=7",
+}
+
+// defaultPasswordEnv is the environment variable holding the password used
+// to authenticate, unless StoreConfig.PasswordEnv is set.
+const defaultPasswordEnv = "ES_PASSWORD"
+
+// StoreConfig describes the store e2e data are kept in.
+type StoreConfig struct {
+	// Backend is the search engine. Defaults to elasticsearch7
+	Backend Backend `json:"backend,omitempty"`
+	// URL, if set, is the URL all indices are accessed at
+	URL string `json:"url,omitempty"`
+	// Username, if set, is used to authenticate (HTTP basic authentication)
+	Username string `json:"username,omitempty"`
+	// PasswordEnv is the environment variable holding the password of
+	// Username. Defaults to ES_PASSWORD
+	PasswordEnv string `json:"passwordEnv,omitempty"`
+	// APIKeyEnv, if set, is the environment variable holding the base64
+	// encoded API key used to authenticate. elasticsearch8 only
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+	// CACert, if set, is a PEM file with the certificate authorities the
+	// certificate of the store is verified with
+	CACert string `json:"caCert,omitempty"`
+	// CertificateFingerprint, if set, is the SHA256 fingerprint (hex) of the
+	// certificate Elasticsearch generated on first start. elasticsearch8 only
+	CertificateFingerprint string `json:"certificateFingerprint,omitempty"`
+	// InsecureSkipVerify, if set, disables the verification of the
+	// certificate of the store
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// LoadStoreConfig reads the store configuration at path (YAML).
+func LoadStoreConfig(path string) (*StoreConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &StoreConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("invalid store configuration %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid store configuration %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate verifies config and sets defaults.
+func (c *StoreConfig) Validate() error {
+	switch c.Backend {
+	case "":
+		c.Backend = Elasticsearch7
+	case Elasticsearch7, Elasticsearch8, OpenSearch:
+	default:
+		return fmt.Errorf("unknown backend %q, must be %s, %s or %s",
+			c.Backend, Elasticsearch7, Elasticsearch8, OpenSearch)
+	}
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid URL %q", c.URL)
+		}
+	}
+	if c.Backend != Elasticsearch8 && (c.APIKeyEnv != "" || c.CertificateFingerprint != "") {
+		return fmt.Errorf("apiKeyEnv and certificateFingerprint require backend %s", Elasticsearch8)
+	}
+	if c.PasswordEnv == "" {
+		c.PasswordEnv = defaultPasswordEnv
+	}
+	return nil
+}
+
+// store is the store configuration set by SetStore.
+var store = &StoreConfig{Backend: Elasticsearch7}
+
+// httpClients caches, by Elasticsearch URL, the client requests of the
+// Elasticsearch 7 client are sent with, so that connections are reused.
+var httpClients sync.Map
+
+// performers caches, by Elasticsearch URL, the go-elasticsearch or
+// opensearch-go client of the store.
+var performers sync.Map
+
+// SetStore configures the store used by all commands. Clients created
+// with the previous configuration are discarded.
+func SetStore(config *StoreConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	previous := store
+	store = config
+
+	// Fail early on unreadable certificate authorities.
+	if _, err := newTransport(); err != nil {
+		store = previous
+		return err
+	}
+
+	resetClients()
+	for _, cache := range []*sync.Map{&httpClients, &performers, &stores} {
+		cache.Range(func(key, _ interface{}) bool {
+			cache.Delete(key)
+			return true
+		})
+	}
+
+	return nil
+}
+
+// storeURL returns the URL of the store if one is configured, defaultURL
+// otherwise.
+func storeURL(defaultURL string) string {
+	if store.URL != "" {
+		return store.URL
+	}
+	return defaultURL
+}
+
+// clientOptions returns the options of the client of esURL for the
+// configured backend.
+func clientOptions(esURL string) ([]elastic.ClientOptionFunc, error) {
+	options := []elastic.ClientOptionFunc{
+		elastic.SetSniff(false),
+		elastic.SetURL(esURL),
+		elastic.SetHealthcheckInterval(healthCheckInterval),
+	}
+	if store.Backend == Elasticsearch7 {
+		if store.Username != "" {
+			options = append(options, elastic.SetBasicAuth(store.Username, os.Getenv(store.PasswordEnv)))
+		}
+		if store.CACert == "" && !store.InsecureSkipVerify {
+			return options, nil
+		}
+	}
+
+	doer, ok := httpClients.Load(esURL)
+	if !ok {
+		var err error
+		doer, err = newHTTPClient(esURL)
+		if err != nil {
+			return nil, err
+		}
+		doer, _ = httpClients.LoadOrStore(esURL, doer)
+	}
+
+	return append(options, elastic.SetHttpClient(doer.(elastic.Doer))), nil
+}
+
+// performer is implemented by the go-elasticsearch and opensearch-go clients.
+type performer interface {
+	Perform(*http.Request) (*http.Response, error)
+}
+
+// performerDoer sends the requests built by the elastic client with a
+// go-elasticsearch or opensearch-go client, in compatibility mode. It is a
+// transport only: the requests and responses are the ones of the elastic
+// client.
+type performerDoer struct {
+	performer
+	// compatibleWith7, if set, asks for the REST API compatibility with
+	// Elasticsearch 7
+	compatibleWith7 bool
+}
+
+func (d performerDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.compatibleWith7 {
+		if mediaType, ok := compatibleWith7[req.Header.Get("Content-Type")]; ok {
+			req.Header.Set("Content-Type", mediaType)
+		}
+		req.Header.Set("Accept", compatibleWith7["application/json"])
+	}
+	return d.Perform(req)
+}
+
+// newHTTPClient returns the client requests of the elastic client to esURL
+// are sent with.
+func newHTTPClient(esURL string) (elastic.Doer, error) {
+	if store.Backend == Elasticsearch7 {
+		transport, err := newTransport()
+		if err != nil {
+			return nil, err
+		}
+		return &http.Client{Transport: transport}, nil
+	}
+
+	p, err := getPerformer(esURL)
+	if err != nil {
+		return nil, err
+	}
+	return performerDoer{performer: p, compatibleWith7: store.Backend == Elasticsearch8}, nil
+}
+
+// getPerformer returns the go-elasticsearch or opensearch-go client of
+// esURL. It is created on first use and shared afterwards.
+func getPerformer(esURL string) (performer, error) {
+	if p, ok := performers.Load(esURL); ok {
+		return p.(performer), nil
+	}
+
+	p, err := newPerformer(esURL)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := performers.LoadOrStore(esURL, p)
+	return actual.(performer), nil
+}
+
+// newPerformer returns the client of the Elasticsearch 8 or OpenSearch
+// store at esURL.
+func newPerformer(esURL string) (performer, error) {
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	// The elastic client sends requests to esURL, path included: the
+	// go-elasticsearch and opensearch-go clients must only set the scheme
+	// and host.
+	u, err := url.Parse(esURL)
+	if err != nil {
+		return nil, err
+	}
+	address := (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+
+	password := ""
+	if store.Username != "" {
+		password = os.Getenv(store.PasswordEnv)
+	}
+
+	if store.Backend == OpenSearch {
+		return opensearch.NewClient(opensearch.Config{
+			Addresses:    []string{address},
+			Username:     store.Username,
+			Password:     password,
+			DisableRetry: true,
+			Transport:    transport,
+		})
+	}
+
+	config := elasticsearch8.Config{
+		Addresses:              []string{address},
+		Username:               store.Username,
+		Password:               password,
+		CertificateFingerprint: store.CertificateFingerprint,
+		// Requests are retried according to the RetryPolicy.
+		DisableRetry: true,
+		Transport:    transport,
+	}
+	if store.APIKeyEnv != "" {
+		config.APIKey = os.Getenv(store.APIKeyEnv)
+	}
+	return elasticsearch8.NewClient(config)
+}
+
+// newTransport returns an HTTP transport verifying the certificate of the
+// store as configured.
+func newTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: store.InsecureSkipVerify,
+	}
+
+	if store.CACert != "" {
+		pem, err := os.ReadFile(store.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", store.CACert)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return transport, nil
+}
@@ -0,0 +1,82 @@
+package es_utils
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// recordingPerformer records the request it performs.
+type recordingPerformer struct {
+	req *http.Request
+}
+
+func (p *recordingPerformer) Perform(req *http.Request) (*http.Response, error) {
+	p.req = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestPerformerDoer(t *testing.T) {
+	tests := []struct {
+		name            string
+		compatibleWith7 bool
+		contentType     string
+		wantContentType string
+		wantAccept      string
+	}{
+		{name: "json", compatibleWith7: true, contentType: "application/json",
+			wantContentType: "application/vnd.elasticsearch+json; compatible-with=7",
+			wantAccept:      "application/vnd.elasticsearch+json; compatible-with=7"},
+		{name: "bulk", compatibleWith7: true, contentType: "application/x-ndjson",
+			wantContentType: "application/vnd.elasticsearch+x-ndjson; compatible-with=7",
+			wantAccept:      "application/vnd.elasticsearch+json; compatible-with=7"},
+		{name: "no body", compatibleWith7: true,
+			wantAccept: "application/vnd.elasticsearch+json; compatible-with=7"},
+		{name: "opensearch", contentType: "application/json", wantContentType: "application/json"},
+	}
+	for _, tt := range tests {
+		p := &recordingPerformer{}
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost:9200/cs_e2e/_search", http.NoBody)
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if _, err := (performerDoer{performer: p, compatibleWith7: tt.compatibleWith7}).Do(req); err != nil {
+			t.Fatalf("%s: Do failed: %v", tt.name, err)
+		}
+		if got := p.req.Header.Get("Content-Type"); got != tt.wantContentType {
+			t.Errorf("%s: Content-Type is %q, want %q", tt.name, got, tt.wantContentType)
+		}
+		if got := p.req.Header.Get("Accept"); got != tt.wantAccept {
+			t.Errorf("%s: Accept is %q, want %q", tt.name, got, tt.wantAccept)
+		}
+	}
+}
+
+func TestSetStore(t *testing.T) {
+	t.Cleanup(func() { _ = SetStore(&StoreConfig{}) })
+
+	if err := SetStore(&StoreConfig{Backend: OpenSearch, URL: "https://search.example.com:9200"}); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	for i := range managedIndices {
+		if got := managedIndices[i].esURL(); got != "https://search.example.com:9200" {
+			t.Errorf("index %s is accessed at %s", managedIndices[i].index, got)
+		}
+	}
+
+	// A configuration with no URL restores the default ones.
+	if err := SetStore(&StoreConfig{}); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	if got := storeURL(resultCloudstackESURL); got != resultCloudstackESURL {
+		t.Errorf("results are accessed at %s, want %s", got, resultCloudstackESURL)
+	}
+
+	err := SetStore(&StoreConfig{Backend: OpenSearch, APIKeyEnv: "ES_API_KEY"})
+	if err == nil || !strings.Contains(err.Error(), "require backend elasticsearch8") {
+		t.Errorf("SetStore error is %v, want apiKeyEnv rejected", err)
+	}
+	if store.Backend != Elasticsearch7 {
+		t.Errorf("invalid configuration was applied: backend is %s", store.Backend)
+	}
+}
@@ -2,22 +2,20 @@ package es_utils
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/olekukonko/tablewriter"
-	elastic "github.com/olivere/elastic/v7"
 )
 
-const (
-	usageCloudstackESURL = "http://172.31.165.56:9200"
-	usageCloudstackIndex = "cs_e2e_usage_entries"
-)
+const usageCloudstackESURL = "http://172.31.165.56:9200"
+
+const usageCloudstackIndex = "cs_e2e_usage_entries"
 
 type UsageReport struct {
 	// Name identifies the pod this usage report is about.
@@ -41,53 +39,60 @@ type UsageReport struct {
 	CreatedTime time.Time `json:"createdTime"`
 }
 
+// GetUsageReports returns usage reports matching the filters, most recent
+// run first.
 func GetUsageReports(ctx context.Context, logger logr.Logger,
 	run, pod string,
 	vcs, ucs bool, maxResult int,
-) (*elastic.SearchResult, error) {
-	c, err := GetClient(usageCloudstackESURL)
+) ([]UsageReport, error) {
+	s, err := getStore(storeURL(usageCloudstackESURL))
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to get client: %v", err))
+		logger.Error(err, "Failed to get client")
 		return nil, err
 	}
 
-	if err = VerifyIndex(ctx, c, usageCloudstackIndex); err != nil {
-		logger.Info(fmt.Sprintf("Failed to verify index %v", err))
+	if err = s.verifyIndex(ctx, logger, usageCloudstackIndex); err != nil {
+		logger.Error(err, "Failed to verify index")
 		return nil, err
 	}
 
-	generalQ := elastic.NewBoolQuery().Should()
+	filters := make([]searchFilter, 0)
 
 	if vcs {
 		logger.Info("Filter by environment:vcs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "vcs"))
+		filters = append(filters, searchFilter{field: "environment", value: "vcs"})
 	} else if ucs {
 		logger.Info("Filter by environment:ucs")
-		generalQ.Filter(elastic.NewMatchQuery("environment", "ucs"))
+		filters = append(filters, searchFilter{field: "environment", value: "ucs"})
 	}
 
 	if run != "" {
 		logger.Info(fmt.Sprintf("Filter by run:%s", run))
-		generalQ.Filter(elastic.NewMatchQuery("run", run))
+		filters = append(filters, searchFilter{field: "run", value: run})
 	}
 
 	if pod != "" {
 		logger.Info(fmt.Sprintf("Filter by report name:%s", pod))
-		generalQ.Filter(elastic.NewTermQuery("name.keyword", pod)) // Exact match
+		filters = append(filters, searchFilter{field: "name.keyword", value: pod, exact: true})
 	}
 
-	searchResult, err := c.Search().Index(usageCloudstackIndex).Query(generalQ).Size(maxResult).
-		SortBy(elastic.NewFieldSort("run").Desc().SortMode("max")).
-		Pretty(true).            // pretty print request and response JSON
-		Do(context.Background()) // execute
+	response, err := s.search(ctx, usageCloudstackIndex, filters, maxResult)
 	if err != nil {
-		logger.Info(fmt.Sprintf("Failed to run query %v", err))
+		logger.Error(err, "Failed to run query")
 		return nil, err
 	}
 
-	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", searchResult.TookInMillis))
+	logger.Info(fmt.Sprintf("Query took %d milliseconds\n", response.took))
+
+	reports := make([]UsageReport, len(response.sources))
+	for i, source := range response.sources {
+		if err := json.Unmarshal(source, &reports[i]); err != nil {
+			return nil, &QueryError{URL: storeURL(usageCloudstackESURL), Index: usageCloudstackIndex,
+				Err: fmt.Errorf("invalid usage report: %w", err)}
+		}
+	}
 
-	return searchResult, nil
+	return reports, nil
 }
 
 func DisplayUsageReport(ctx context.Context, logger logr.Logger,
@@ -95,7 +100,7 @@ func DisplayUsageReport(ctx context.Context, logger logr.Logger,
 	vcs, ucs bool,
 	maxResult int,
 ) error {
-	searchResult, err := GetUsageReports(ctx, logger, run, pod, vcs, ucs, maxResult)
+	reports, err := GetUsageReports(ctx, logger, run, pod, vcs, ucs, maxResult)
 	if err != nil {
 		return err
 	}
@@ -105,9 +110,7 @@ func DisplayUsageReport(ctx context.Context, logger logr.Logger,
 	table.SetAutoWrapText(false)
 	table.SetRowLine(true)
 
-	var rtyp UsageReport
-	for _, item := range searchResult.Each(reflect.TypeOf(rtyp)) {
-		r := item.(UsageReport)
+	for _, r := range reports {
 		if usageType == "" || strings.EqualFold(usageType, "memory") {
 			table.Append([]string{r.Environment, strconv.Itoa(r.Run),
 				r.Name, "Memory", fmt.Sprintf("%dKi", r.Memory), fmt.Sprintf("%dKi", r.MemoryLimit)})
@@ -2,34 +2,93 @@ package es_utils
 
 import (
 	"context"
-	"fmt"
+	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	elastic "github.com/olivere/elastic/v7"
 )
 
 const (
 	healthCheckInterval = 10 * time.Second
+
+	// MaxQuerySize is the maximum number of documents a single query can return
+	// (Elasticsearch default index.max_result_window).
+	MaxQuerySize = 10000
+
+	// bulkSize is the maximum number of documents sent in a single bulk request.
+	bulkSize = 500
 )
 
-// GetClient returns elastic client
+// clients caches the client of each Elasticsearch URL. A client runs a
+// healthcheck goroutine and keeps connections open: one client per URL is
+// shared by the whole process, i.e. by all requests handled by serve.
+var clients sync.Map
+
+// GetClient returns elastic client, sending requests with the client of the
+// backend configured by SetStore. The client is created on first use and
+// shared afterwards.
+// Connection is retried according to the configured RetryPolicy. If
+// Elasticsearch cannot be reached a ConnectionError is returned.
 func GetClient(esURL string) (*elastic.Client, error) {
-	return elastic.NewClient(
-		elastic.SetSniff(false),
-		elastic.SetURL(esURL),
-		elastic.SetHealthcheckInterval(healthCheckInterval),
-	)
-}
+	if c, ok := clients.Load(esURL); ok {
+		return c.(*elastic.Client), nil
+	}
 
-func VerifyIndex(ctx context.Context, c *elastic.Client, index string) error {
-	exists, err := c.IndexExists(index).Do(ctx)
+	options, err := clientOptions(esURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *elastic.Client
+	attempts, err := withRetry(context.Background(), func() error {
+		var err error
+		c, err = elastic.NewClient(options...)
+		return err
+	})
 	if err != nil {
+		return nil, &ConnectionError{URL: esURL, Attempts: attempts, Err: err}
+	}
+
+	// Concurrent callers may have created a client for the same URL: keep
+	// only one.
+	if actual, loaded := clients.LoadOrStore(esURL, c); loaded {
+		c.Stop()
+		return actual.(*elastic.Client), nil
+	}
+
+	return c, nil
+}
+
+// resetClients stops and discards the cached clients.
+func resetClients() {
+	clients.Range(func(key, c interface{}) bool {
+		c.(*elastic.Client).Stop()
+		clients.Delete(key)
+		return true
+	})
+}
+
+// VerifyIndex verifies index exists. It returns an IndexNotFoundError if
+// it does not, and a SchemaVersionError if index was created by a more
+// recent version of e2e_result.
+func VerifyIndex(ctx context.Context, logger logr.Logger, c *elastic.Client, esURL, index string) error {
+	var exists bool
+	attempts, err := withRetry(ctx, func() error {
+		var err error
+		exists, err = c.IndexExists(index).Do(ctx)
 		return err
+	})
+	if err != nil {
+		if isTransient(err) {
+			return &ConnectionError{URL: esURL, Attempts: attempts, Err: err}
+		}
+		return &QueryError{URL: esURL, Index: index, Err: err}
 	}
 
 	if !exists {
-		return fmt.Errorf("%s index does not exist", index)
+		return &IndexNotFoundError{URL: esURL, Index: index}
 	}
 
-	return nil
+	return checkSchemaVersion(ctx, logger, c, esURL, index)
 }
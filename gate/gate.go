@@ -0,0 +1,160 @@
+package gate
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Verdict is the outcome of evaluating a rule.
+type Verdict struct {
+	Rule *Rule
+	// Passed is true if rule is satisfied
+	Passed bool
+	// Details explains the verdict
+	Details string
+}
+
+// Evaluate evaluates every rule of policy against data.
+func Evaluate(policy *Policy, data *es_utils.RunData) []Verdict {
+	verdicts := make([]Verdict, len(policy.Rules))
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		verdicts[i] = Verdict{Rule: rule}
+		switch rule.Kind {
+		case NoNewFailures:
+			verdicts[i].Passed, verdicts[i].Details = evaluateNoNewFailures(data)
+		case PassRate:
+			verdicts[i].Passed, verdicts[i].Details = evaluatePassRate(rule, data)
+		case ReportDuration:
+			verdicts[i].Passed, verdicts[i].Details = evaluateReportDuration(rule, data)
+		case PodUsage:
+			verdicts[i].Passed, verdicts[i].Details = evaluatePodUsage(rule, data)
+		}
+	}
+
+	return verdicts
+}
+
+// Violated returns true if at least one verdict did not pass.
+func Violated(verdicts []Verdict) bool {
+	for i := range verdicts {
+		if !verdicts[i].Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// DisplayVerdicts displays one row per verdict.
+func DisplayVerdicts(data *es_utils.RunData, verdicts []Verdict) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENVIRONMENT", "RUN", "RULE", "VERDICT", "DETAILS"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for i := range verdicts {
+		verdict := "PASS"
+		if !verdicts[i].Passed {
+			verdict = "FAIL"
+		}
+		table.Append([]string{data.Environment, strconv.Itoa(data.Run),
+			verdicts[i].Rule.Description(), verdict, verdicts[i].Details})
+	}
+
+	table.Render()
+}
+
+func evaluateNoNewFailures(data *es_utils.RunData) (bool, string) {
+	if data.PreviousRun == 0 {
+		return true, "no previous run to compare with"
+	}
+
+	newFailures := make([]string, 0)
+	for _, r := range data.NewFailures() {
+		newFailures = append(newFailures, r.Name)
+	}
+
+	if len(newFailures) == 0 {
+		return true, fmt.Sprintf("no new failures vs run %d", data.PreviousRun)
+	}
+
+	sort.Strings(newFailures)
+	return false, fmt.Sprintf("new failures vs run %d: %s", data.PreviousRun, strings.Join(newFailures, ", "))
+}
+
+func evaluatePassRate(rule *Rule, data *es_utils.RunData) (bool, string) {
+	passed, failed, _ := data.Counts()
+	executed := passed + failed
+
+	if executed == 0 {
+		return false, "no executed tests"
+	}
+
+	rate := float64(passed) * 100 / float64(executed)
+	return rate >= rule.MinPassRate, fmt.Sprintf("pass rate %.2f%% (%d/%d)", rate, passed, executed)
+}
+
+func evaluateReportDuration(rule *Rule, data *es_utils.RunData) (bool, string) {
+	durations := make([]float64, 0)
+	for i := range data.Reports {
+		r := &data.Reports[i]
+		if r.Type != rule.ReportType {
+			continue
+		}
+		if rule.ReportSubType != "" && r.SubType != rule.ReportSubType {
+			continue
+		}
+		durations = append(durations, r.DurationInMinutes)
+	}
+
+	if len(durations) == 0 {
+		return false, "no matching reports"
+	}
+
+	p := percentile(durations, rule.Percentile)
+	return p <= rule.MaxMinutes, fmt.Sprintf("p%g is %.2f minutes over %d report(s)", rule.Percentile, p, len(durations))
+}
+
+func evaluatePodUsage(rule *Rule, data *es_utils.RunData) (bool, string) {
+	offenders := make([]string, 0)
+	for i := range data.UsageReports {
+		u := &data.UsageReports[i]
+		used, limit, unit := u.Memory, u.MemoryLimit, "Ki"
+		if rule.Resource == "cpu" {
+			used, limit, unit = u.CPU, u.CPULimit, "m"
+		}
+		if limit <= 0 {
+			continue
+		}
+		if percent := float64(used) * 100 / float64(limit); percent > rule.MaxPercentOfLimit {
+			offenders = append(offenders, fmt.Sprintf("%s (%d%s/%d%s, %.0f%%)", u.Name, used, unit, limit, unit, percent))
+		}
+	}
+
+	if len(offenders) == 0 {
+		return true, fmt.Sprintf("%d pod(s) within limits", len(data.UsageReports))
+	}
+
+	sort.Strings(offenders)
+	return false, strings.Join(offenders, ", ")
+}
+
+// percentile returns the p-th percentile of values using the nearest-rank method.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
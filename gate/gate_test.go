@@ -0,0 +1,133 @@
+package gate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+func testRunData() *es_utils.RunData {
+	return &es_utils.RunData{
+		Environment: "vcs",
+		Run:         2927,
+		PreviousRun: 2926,
+		Results: []es_utils.Result{
+			{Name: "install", Result: "passed"},
+			{Name: "scale", Result: "passed"},
+			{Name: "upgrade", Result: "failed"},
+			{Name: "backup", Result: "failed"},
+			{Name: "restore", Result: "skipped"},
+		},
+		PreviousResults: []es_utils.Result{
+			{Name: "backup", Result: "failed"},
+		},
+		Reports: []es_utils.Report{
+			{Type: "deploy", SubType: "cluster", DurationInMinutes: 10},
+			{Type: "deploy", SubType: "cluster", DurationInMinutes: 20},
+			{Type: "deploy", SubType: "addon", DurationInMinutes: 40},
+			{Type: "upgrade", DurationInMinutes: 90},
+		},
+		UsageReports: []es_utils.UsageReport{
+			{Name: "controller", Memory: 900, MemoryLimit: 1000, CPU: 100, CPULimit: 1000},
+			{Name: "agent", Memory: 500, MemoryLimit: 1000},
+			{Name: "unbounded", Memory: 5000},
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		rule       Rule
+		wantPassed bool
+		wantDetail string
+	}{
+		{rule: Rule{Kind: NoNewFailures}, wantDetail: "new failures vs run 2926: upgrade"},
+		{rule: Rule{Kind: PassRate, MinPassRate: 50}, wantPassed: true, wantDetail: "pass rate 50.00% (2/4)"},
+		{rule: Rule{Kind: PassRate, MinPassRate: 75}, wantDetail: "pass rate 50.00% (2/4)"},
+		{rule: Rule{Kind: ReportDuration, ReportType: "deploy", Percentile: 50, MaxMinutes: 20}, wantPassed: true,
+			wantDetail: "p50 is 20.00 minutes over 3 report(s)"},
+		{rule: Rule{Kind: ReportDuration, ReportType: "deploy", Percentile: 90, MaxMinutes: 20},
+			wantDetail: "p90 is 40.00 minutes over 3 report(s)"},
+		{rule: Rule{Kind: ReportDuration, ReportType: "deploy", ReportSubType: "cluster", Percentile: 90, MaxMinutes: 20},
+			wantPassed: true, wantDetail: "p90 is 20.00 minutes over 2 report(s)"},
+		{rule: Rule{Kind: ReportDuration, ReportType: "backup", Percentile: 90, MaxMinutes: 20},
+			wantDetail: "no matching reports"},
+		{rule: Rule{Kind: PodUsage, Resource: "memory", MaxPercentOfLimit: 80},
+			wantDetail: "controller (900Ki/1000Ki, 90%)"},
+		{rule: Rule{Kind: PodUsage, Resource: "cpu", MaxPercentOfLimit: 80}, wantPassed: true,
+			wantDetail: "3 pod(s) within limits"},
+	}
+	for _, tt := range tests {
+		verdicts := Evaluate(&Policy{Rules: []Rule{tt.rule}}, testRunData())
+		if len(verdicts) != 1 {
+			t.Fatalf("got %d verdicts, want 1", len(verdicts))
+		}
+		v := verdicts[0]
+		if v.Passed != tt.wantPassed || v.Details != tt.wantDetail {
+			t.Errorf("%s: verdict is (%v, %q), want (%v, %q)",
+				tt.rule.Description(), v.Passed, v.Details, tt.wantPassed, tt.wantDetail)
+		}
+		if Violated(verdicts) == tt.wantPassed {
+			t.Errorf("%s: Violated() = %v", tt.rule.Description(), !tt.wantPassed)
+		}
+	}
+}
+
+func TestEvaluateNoPreviousRun(t *testing.T) {
+	data := testRunData()
+	data.PreviousRun, data.PreviousResults = 0, nil
+
+	verdicts := Evaluate(&Policy{Rules: []Rule{{Kind: NoNewFailures}}}, data)
+	if !verdicts[0].Passed || verdicts[0].Details != "no previous run to compare with" {
+		t.Errorf("verdict is (%v, %q)", verdicts[0].Passed, verdicts[0].Details)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: "rules:\n- kind: noNewFailures\n- kind: reportDuration\n  reportType: deploy\n  maxMinutes: 30\n"},
+		{name: "no rules", content: "rules: []\n", wantErr: "contains no rules"},
+		{name: "unknown kind", content: "rules:\n- kind: flakiness\n", wantErr: `rule 1: unknown kind "flakiness"`},
+		{name: "pass rate out of range", content: "rules:\n- kind: passRate\n  minPassRate: 120\n",
+			wantErr: "minPassRate must be in (0, 100]"},
+		{name: "invalid resource", content: "rules:\n- kind: podUsage\n  resource: disk\n  maxPercentOfLimit: 80\n",
+			wantErr: "resource must be memory or cpu"},
+		{name: "unknown field", content: "rules:\n- kind: passRate\n  threshold: 90\n", wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("failed to write policy: %v", err)
+		}
+		policy, err := LoadPolicy(path)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: LoadPolicy failed: %v", tt.name, err)
+				continue
+			}
+			if got := policy.Rules[1].Percentile; got != 90 {
+				t.Errorf("%s: default percentile is %g, want 90", tt.name, got)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: LoadPolicy error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{15, 20, 35, 40, 50}
+	for _, tt := range []struct{ p, want float64 }{{0, 15}, {30, 20}, {40, 20}, {50, 35}, {100, 50}} {
+		if got := percentile(values, tt.p); got != tt.want {
+			t.Errorf("percentile(%g) = %g, want %g", tt.p, got, tt.want)
+		}
+	}
+}
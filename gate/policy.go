@@ -0,0 +1,141 @@
+package gate
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RuleKind identifies what a rule verifies.
+type RuleKind string
+
+const (
+	// NoNewFailures is violated if a test failed in the gated run but did not
+	// fail in the previous run of the same environment.
+	NoNewFailures RuleKind = "noNewFailures"
+	// PassRate is violated if the percentage of passed tests (skipped tests
+	// excluded) is below MinPassRate.
+	PassRate RuleKind = "passRate"
+	// ReportDuration is violated if the Percentile of durations of reports of
+	// type ReportType (and ReportSubType if set) exceeds MaxMinutes.
+	ReportDuration RuleKind = "reportDuration"
+	// PodUsage is violated if any pod used more than MaxPercentOfLimit of its
+	// Resource (memory or cpu) limit. Pods with no limit are ignored.
+	PodUsage RuleKind = "podUsage"
+)
+
+// Rule is a single quality threshold.
+type Rule struct {
+	// Name is an optional human readable description of the rule
+	Name string `json:"name,omitempty"`
+	// Kind is the type of the rule
+	Kind RuleKind `json:"kind"`
+	// MinPassRate is the minimum pass rate percentage. Used by passRate.
+	MinPassRate float64 `json:"minPassRate,omitempty"`
+	// ReportType is the report type. Used by reportDuration.
+	ReportType string `json:"reportType,omitempty"`
+	// ReportSubType is an optional report subtype. Used by reportDuration.
+	ReportSubType string `json:"reportSubType,omitempty"`
+	// Percentile of report durations to verify (default is 90). Used by reportDuration.
+	Percentile float64 `json:"percentile,omitempty"`
+	// MaxMinutes is the maximum allowed duration. Used by reportDuration.
+	MaxMinutes float64 `json:"maxMinutes,omitempty"`
+	// Resource is either memory (default) or cpu. Used by podUsage.
+	Resource string `json:"resource,omitempty"`
+	// MaxPercentOfLimit is the maximum usage allowed as percentage of the
+	// limit. Used by podUsage.
+	MaxPercentOfLimit float64 `json:"maxPercentOfLimit,omitempty"`
+}
+
+// Policy is the list of rules a run must satisfy.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("policy %s contains no rules", path)
+	}
+
+	for i := range policy.Rules {
+		if err := policy.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("policy %s, rule %d: %w", path, i+1, err)
+		}
+	}
+
+	return policy, nil
+}
+
+func (r *Rule) validate() error {
+	switch r.Kind {
+	case NoNewFailures:
+	case PassRate:
+		if r.MinPassRate <= 0 || r.MinPassRate > 100 {
+			return fmt.Errorf("minPassRate must be in (0, 100]")
+		}
+	case ReportDuration:
+		if r.ReportType == "" {
+			return fmt.Errorf("reportType is required")
+		}
+		if r.MaxMinutes <= 0 {
+			return fmt.Errorf("maxMinutes must be positive")
+		}
+		if r.Percentile == 0 {
+			r.Percentile = 90
+		}
+		if r.Percentile < 0 || r.Percentile > 100 {
+			return fmt.Errorf("percentile must be in (0, 100]")
+		}
+	case PodUsage:
+		if r.Resource == "" {
+			r.Resource = "memory"
+		}
+		if r.Resource != "memory" && r.Resource != "cpu" {
+			return fmt.Errorf("resource must be memory or cpu")
+		}
+		if r.MaxPercentOfLimit <= 0 {
+			return fmt.Errorf("maxPercentOfLimit must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+
+	return nil
+}
+
+// Description returns rule name if set, a description built from its
+// parameters otherwise.
+func (r *Rule) Description() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	switch r.Kind {
+	case NoNewFailures:
+		return "no new failures vs previous run"
+	case PassRate:
+		return fmt.Sprintf("pass rate >= %g%%", r.MinPassRate)
+	case ReportDuration:
+		reportType := r.ReportType
+		if r.ReportSubType != "" {
+			reportType = fmt.Sprintf("%s/%s", r.ReportType, r.ReportSubType)
+		}
+		return fmt.Sprintf("%s p%g <= %g minutes", reportType, r.Percentile, r.MaxMinutes)
+	case PodUsage:
+		return fmt.Sprintf("no pod over %g%% of %s limit", r.MaxPercentOfLimit, r.Resource)
+	}
+
+	return string(r.Kind)
+}
@@ -1,18 +1,23 @@
 module github.com/gianlucam76/cs-e2e-result
 
-go 1.17
+go 1.20
 
 require (
 	github.com/docopt/docopt-go v0.0.0-20180111231733-ee0de3bc6815
+	github.com/elastic/go-elasticsearch/v8 v8.11.1
 	github.com/go-logr/logr v1.2.2
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/olivere/elastic/v7 v7.0.32
+	github.com/opensearch-project/opensearch-go/v2 v2.3.0
 	k8s.io/klog/v2 v2.60.1
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
+	github.com/elastic/elastic-transport-go/v8 v8.3.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,97 @@
+package ingest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// Slug returns the name artifacts of a test are collected under: test name
+// lowercased, with every sequence of characters other than letters, digits,
+// '_' and '-' replaced by a single '-'.
+// For instance "Deploy cluster [Serial]" becomes "deploy-cluster-serial".
+func Slug(test string) string {
+	return strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(test), "-"), "-")
+}
+
+// AttachArtifacts looks, in dir, for the artifacts of each result and appends
+// them to the result artifacts. Artifacts of a test are:
+//   - all files, at any depth, in the directory <dir>/<slug>;
+//   - files directly in dir named <slug>.<anything>, e.g. <slug>.log or
+//     <slug>.must-gather.tar.gz.
+//
+// where slug is the Slug of the test name. The URL of an artifact is its path
+// relative to dir appended to baseURL or, if baseURL is empty, a file URL.
+// AttachArtifacts returns the number of artifacts attached.
+func AttachArtifacts(results []es_utils.Result, dir, baseURL string) (int, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read artifacts directory: %w", err)
+	}
+
+	attached := 0
+	for i := range results {
+		slug := Slug(results[i].Name)
+		if slug == "" {
+			continue
+		}
+
+		var artifacts []es_utils.Artifact
+		for _, entry := range entries {
+			switch {
+			case entry.IsDir() && entry.Name() == slug:
+				err = filepath.Walk(filepath.Join(dir, slug), func(path string, info os.FileInfo, err error) error {
+					if err != nil || info.IsDir() {
+						return err
+					}
+					name, err := filepath.Rel(filepath.Join(dir, slug), path)
+					if err != nil {
+						return err
+					}
+					artifacts = append(artifacts, newArtifact(dir, path, filepath.ToSlash(name), baseURL))
+					return nil
+				})
+				if err != nil {
+					return attached, fmt.Errorf("failed to read artifacts of %s: %w", results[i].Name, err)
+				}
+			case !entry.IsDir() && strings.HasPrefix(entry.Name(), slug+"."):
+				artifacts = append(artifacts, newArtifact(dir, filepath.Join(dir, entry.Name()), entry.Name(), baseURL))
+			}
+		}
+
+		sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+		results[i].Artifacts = append(results[i].Artifacts, artifacts...)
+		attached += len(artifacts)
+	}
+
+	return attached, nil
+}
+
+// newArtifact returns the artifact for file path in artifacts directory dir.
+func newArtifact(dir, path, name, baseURL string) es_utils.Artifact {
+	if baseURL == "" {
+		return es_utils.Artifact{Name: name, URL: (&url.URL{Scheme: "file", Path: path}).String()}
+	}
+
+	// Paths returned by filepath.Walk are always below dir.
+	rel, _ := filepath.Rel(dir, path)
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	return es_utils.Artifact{Name: name, URL: strings.TrimRight(baseURL, "/") + "/" + strings.Join(segments, "/")}
+}
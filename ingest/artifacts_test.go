@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		test string
+		want string
+	}{
+		{test: "Deploy cluster [Serial]", want: "deploy-cluster-serial"},
+		{test: "upgrade_to-v2", want: "upgrade_to-v2"},
+		{test: "  Scale: 3 to 5 nodes!  ", want: "scale-3-to-5-nodes"},
+		{test: "a -> b", want: "a---b"},
+		{test: "[]", want: ""},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.test); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.test, got, tt.want)
+		}
+	}
+}
+
+// writeArtifacts creates files, given by path relative to a temporary
+// directory, and returns the directory.
+func writeArtifacts(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0o600); err != nil {
+			t.Fatalf("failed to write artifact: %v", err)
+		}
+	}
+	return dir
+}
+
+// artifactNames returns the names of artifacts.
+func artifactNames(artifacts []es_utils.Artifact) []string {
+	names := make([]string, len(artifacts))
+	for i := range artifacts {
+		names[i] = artifacts[i].Name
+	}
+	return names
+}
+
+func TestAttachArtifacts(t *testing.T) {
+	dir := writeArtifacts(t,
+		"deploy/pods/controller.log",
+		"deploy/events.json",
+		"deploy.must-gather.tar.gz",
+		"deploy-cluster.log",
+		"deploy-cluster/screenshots/step 1.png",
+		"deploy-clusters.log",
+		"upgrade.log",
+	)
+
+	results := []es_utils.Result{
+		{Name: "Deploy"},
+		{Name: "Deploy cluster"},
+		{Name: "backup"},
+		{Name: "Upgrade", Artifacts: []es_utils.Artifact{{Name: "console", URL: "https://ci.example.com/console"}}},
+	}
+	attached, err := AttachArtifacts(results, dir, "")
+	if err != nil {
+		t.Fatalf("AttachArtifacts failed: %v", err)
+	}
+	if attached != 6 {
+		t.Errorf("attached %d artifacts, want 6", attached)
+	}
+
+	want := [][]string{
+		{"deploy.must-gather.tar.gz", "events.json", "pods/controller.log"},
+		{"deploy-cluster.log", "screenshots/step 1.png"},
+		{},
+		{"console", "upgrade.log"},
+	}
+	for i := range results {
+		if got := artifactNames(results[i].Artifacts); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("%s: artifacts are %v, want %v", results[i].Name, got, want[i])
+		}
+	}
+
+	// Without --artifacts-url, URLs are file URLs of the artifacts.
+	u, err := url.Parse(results[1].Artifacts[1].URL)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", results[1].Artifacts[1].URL, err)
+	}
+	if wantPath := filepath.Join(dir, "deploy-cluster", "screenshots", "step 1.png"); u.Scheme != "file" || u.Path != wantPath {
+		t.Errorf("URL is %s, want file URL of %s", u, wantPath)
+	}
+}
+
+func TestAttachArtifactsURL(t *testing.T) {
+	dir := writeArtifacts(t, "deploy-cluster/logs/step 1#100%.log", "upgrade.log")
+
+	results := []es_utils.Result{{Name: "Deploy cluster"}, {Name: "upgrade"}}
+	if _, err := AttachArtifacts(results, dir, "https://ci.example.com/job/42/artifacts/"); err != nil {
+		t.Fatalf("AttachArtifacts failed: %v", err)
+	}
+
+	want := [][]es_utils.Artifact{
+		{{Name: "logs/step 1#100%.log", URL: "https://ci.example.com/job/42/artifacts/deploy-cluster/logs/step%201%23100%25.log"}},
+		{{Name: "upgrade.log", URL: "https://ci.example.com/job/42/artifacts/upgrade.log"}},
+	}
+	for i := range results {
+		if !reflect.DeepEqual(results[i].Artifacts, want[i]) {
+			t.Errorf("%s: artifacts are %v, want %v", results[i].Name, results[i].Artifacts, want[i])
+		}
+	}
+}
+
+func TestNewArtifact(t *testing.T) {
+	dir := filepath.FromSlash("/ci/artifacts")
+	path := filepath.Join(dir, "deploy cluster#1", "logs", "100%.log")
+
+	got := newArtifact(dir, path, "logs/100%.log", "https://ci.example.com/job/42/artifacts/")
+	want := "https://ci.example.com/job/42/artifacts/deploy%20cluster%231/logs/100%25.log"
+	if got.Name != "logs/100%.log" || got.URL != want {
+		t.Errorf("newArtifact() = %+v, want URL %s", got, want)
+	}
+
+	got = newArtifact(dir, path, "logs/100%.log", "")
+	if !strings.HasPrefix(got.URL, "file:///") || !strings.HasSuffix(got.URL, "/deploy%20cluster%231/logs/100%25.log") {
+		t.Errorf("newArtifact() URL is %s, want an escaped file URL", got.URL)
+	}
+}
+
+func TestAttachArtifactsMissingDir(t *testing.T) {
+	_, err := AttachArtifacts([]es_utils.Result{{Name: "upgrade"}}, filepath.Join(t.TempDir(), "missing"), "")
+	if err == nil || !strings.Contains(err.Error(), "failed to read artifacts directory") {
+		t.Errorf("AttachArtifacts error = %v, want missing directory reported", err)
+	}
+}
@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// junitTestSuites is the root element of a JUnit report. Reports with a
+// single testsuite root element are supported as well.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Status    string        `xml:"status,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ginkgoLocation matches the location Ginkgo reports a failure at, i.e.
+// "In [It] at: /path/cluster_test.go:42 @ 10/01/26 10:12:13.123".
+var ginkgoLocation = regexp.MustCompile(`at: (\S+:\d+)`)
+
+// LoadJUnit reads a JUnit XML report, as produced by Ginkgo, and returns a
+// result per test case. Failure message and location are extracted from
+// failure elements.
+func LoadJUnit(path string) ([]es_utils.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		var suite junitTestSuite
+		if err := xml.Unmarshal(data, &suite); err != nil {
+			return nil, fmt.Errorf("failed to parse JUnit report %s: %w", path, err)
+		}
+		suites.Suites = []junitTestSuite{suite}
+	}
+
+	results := make([]es_utils.Result, 0)
+	for i := range suites.Suites {
+		for j := range suites.Suites[i].Cases {
+			results = append(results, junitResult(&suites.Suites[i].Cases[j]))
+		}
+	}
+
+	return results, nil
+}
+
+func junitResult(tc *junitTestCase) es_utils.Result {
+	duration := time.Duration(tc.Time * float64(time.Second))
+	r := es_utils.Result{
+		Name:              strings.TrimSpace(strings.TrimPrefix(tc.Name, "[It]")),
+		Description:       tc.Classname,
+		DurationInMinutes: duration.Minutes(),
+		DurationInSecond:  duration,
+		Result:            "passed",
+		Serial:            strings.Contains(tc.Name, "[Serial]"),
+	}
+
+	failure := tc.Failure
+	if failure == nil {
+		failure = tc.Error
+	}
+
+	switch {
+	case failure != nil || tc.Status == "failed":
+		r.Result = "failed"
+		if failure != nil {
+			r.FailureMessage = failureMessage(failure)
+			if m := ginkgoLocation.FindStringSubmatch(failure.Text); m != nil {
+				r.FailureLocation = m[1]
+			}
+		}
+	case tc.Skipped != nil || tc.Status == "skipped" || tc.Status == "pending":
+		r.Result = "skipped"
+	}
+
+	return r
+}
+
+// failureMessage returns the message attribute of the failure or, if not
+// set, the failure text without Ginkgo "[FAILED]" marker.
+func failureMessage(f *junitFailure) string {
+	message := strings.TrimSpace(f.Message)
+	if message == "" {
+		message = strings.TrimSpace(f.Text)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(message, "[FAILED]"))
+}
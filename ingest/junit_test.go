@@ -0,0 +1,107 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// passedResult returns the passed result of test name which lasted seconds.
+func passedResult(name, suite string, seconds float64, serial bool) es_utils.Result {
+	d := time.Duration(seconds * float64(time.Second))
+	return es_utils.Result{Name: name, Description: suite, Result: "passed", Serial: serial,
+		DurationInMinutes: d.Minutes(), DurationInSecond: d}
+}
+
+func TestLoadJUnit(t *testing.T) {
+	upgrade := passedResult("Upgrade cluster", "E2E Suite", 1200, false)
+	upgrade.Result = "failed"
+	upgrade.FailureMessage = "Timed out after 1200.000s.\nExpected cluster to be upgraded"
+	upgrade.FailureLocation = "/workspace/test/e2e/upgrade_test.go:87"
+
+	backup := passedResult("Backup cluster", "E2E Suite", 3.2, false)
+	backup.Result = "failed"
+	backup.FailureMessage = "[PANICKED] Test Panicked\nruntime error: invalid memory address or nil pointer dereference\n" +
+		"In [It] at: /workspace/test/e2e/backup_test.go:41 @ 10/01/26 02:31:00.001"
+	backup.FailureLocation = "/workspace/test/e2e/backup_test.go:41"
+
+	restore := passedResult("Restore cluster", "E2E Suite", 0, false)
+	restore.Result = "skipped"
+
+	scale := passedResult("Scale cluster", "E2E Suite", 120.51, false)
+	scale.Result = "failed"
+	scale.FailureMessage = "expected 5 nodes, got 3"
+
+	login := passedResult("login", "smoke", 2, false)
+	login.Result = "skipped"
+
+	tests := []struct {
+		file string
+		want []es_utils.Result
+	}{
+		{file: "ginkgo-report.xml", want: []es_utils.Result{
+			passedResult("Deploy cluster [Serial]", "E2E Suite", 610.5, true), upgrade, backup, restore, scale}},
+		{file: "single-testsuite.xml", want: []es_utils.Result{passedResult("install", "smoke", 10.5, false), login}},
+	}
+	for _, tt := range tests {
+		results, err := LoadJUnit(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatalf("%s: LoadJUnit failed: %v", tt.file, err)
+		}
+		if len(results) != len(tt.want) {
+			t.Fatalf("%s: got %d results, want %d", tt.file, len(results), len(tt.want))
+		}
+		for i := range tt.want {
+			if !reflect.DeepEqual(results[i], tt.want[i]) {
+				t.Errorf("%s: result %d is %+v, want %+v", tt.file, i, results[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestLoadJUnitInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := os.WriteFile(path, []byte(`{"tests": []}`), 0o600); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	_, err := LoadJUnit(path)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse JUnit report") {
+		t.Errorf("LoadJUnit error = %v, want parse error", err)
+	}
+}
+
+func TestJUnitResult(t *testing.T) {
+	tests := []struct {
+		name         string
+		tc           junitTestCase
+		wantResult   string
+		wantMessage  string
+		wantLocation string
+	}{
+		{name: "failed status without failure element", tc: junitTestCase{Status: "failed"}, wantResult: "failed"},
+		{name: "pending", tc: junitTestCase{Status: "pending"}, wantResult: "skipped"},
+		{name: "failure over error", tc: junitTestCase{
+			Failure: &junitFailure{Message: "[FAILED] from failure"},
+			Error:   &junitFailure{Message: "from error"},
+		}, wantResult: "failed", wantMessage: "from failure"},
+		{name: "location of a nested step", tc: junitTestCase{
+			Failure: &junitFailure{Text: "[FAILED] boom\nIn [BeforeEach] at: /e2e/suite_test.go:12 @ 10/01/26 02:00:00.000\n" +
+				"In [It] at: /e2e/scale_test.go:30 @ 10/01/26 02:01:00.000"},
+		}, wantResult: "failed", wantMessage: "boom\nIn [BeforeEach] at: /e2e/suite_test.go:12 @ 10/01/26 02:00:00.000\n" +
+			"In [It] at: /e2e/scale_test.go:30 @ 10/01/26 02:01:00.000", wantLocation: "/e2e/suite_test.go:12"},
+		{name: "no location", tc: junitTestCase{Failure: &junitFailure{Text: "at: somewhere"}},
+			wantResult: "failed", wantMessage: "at: somewhere"},
+	}
+	for _, tt := range tests {
+		r := junitResult(&tt.tc)
+		if r.Result != tt.wantResult || r.FailureMessage != tt.wantMessage || r.FailureLocation != tt.wantLocation {
+			t.Errorf("%s: result is (%q, %q, %q), want (%q, %q, %q)", tt.name,
+				r.Result, r.FailureMessage, r.FailureLocation, tt.wantResult, tt.wantMessage, tt.wantLocation)
+		}
+	}
+}
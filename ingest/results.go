@@ -0,0 +1,34 @@
+// Package ingest converts test reports into results which can be pushed.
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// LoadResults reads a JSON file containing an array of results, in the
+// format they are stored with.
+func LoadResults(path string) ([]es_utils.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []es_utils.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse results file %s: %w", path, err)
+	}
+
+	return results, nil
+}
+
+// SetRun sets environment and run of all results.
+func SetRun(results []es_utils.Result, env string, run int) {
+	for i := range results {
+		results[i].Environment = env
+		results[i].Run = run
+	}
+}
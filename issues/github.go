@@ -0,0 +1,160 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGitHubURL is the GitHub REST API endpoint
+	DefaultGitHubURL = "https://api.github.com"
+	githubTimeout    = 30 * time.Second
+	githubPageSize   = 100
+)
+
+// GitHubTracker files issues in a GitHub repository using the REST API.
+// Only issues carrying Label are considered when looking for existing ones.
+type GitHubTracker struct {
+	// BaseURL is the REST API endpoint (DefaultGitHubURL or GitHub Enterprise)
+	BaseURL string
+	// Repo is the repository in owner/name form
+	Repo string
+	// Token is the token used to authenticate
+	Token string
+	// Label is added to every issue filed
+	Label string
+
+	client *http.Client
+}
+
+// NewGitHubTracker returns a GitHubTracker.
+func NewGitHubTracker(baseURL, repo, token, label string) (*GitHubTracker, error) {
+	if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid GitHub repository %q (expected owner/name)", repo)
+	}
+	if label == "" {
+		return nil, fmt.Errorf("a label is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultGitHubURL
+	}
+
+	return &GitHubTracker{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Repo:    repo,
+		Token:   token,
+		Label:   label,
+		client:  &http.Client{Timeout: githubTimeout},
+	}, nil
+}
+
+type githubIssue struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	HTMLURL     string          `json:"html_url"`
+	PullRequest json.RawMessage `json:"pull_request,omitempty"`
+}
+
+func (i *githubIssue) toIssue() *Issue {
+	return &Issue{ID: i.Number, Title: i.Title, Body: i.Body, URL: i.HTMLURL}
+}
+
+// FindOpenIssue implements IssueTracker.
+func (t *GitHubTracker) FindOpenIssue(ctx context.Context, title string) (*Issue, error) {
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", "open")
+		query.Set("labels", t.Label)
+		query.Set("per_page", fmt.Sprint(githubPageSize))
+		query.Set("page", fmt.Sprint(page))
+
+		var list []githubIssue
+		if err := t.do(ctx, http.MethodGet, "/repos/"+t.Repo+"/issues?"+query.Encode(), nil, &list); err != nil {
+			return nil, err
+		}
+
+		for i := range list {
+			if list[i].PullRequest == nil && list[i].Title == title {
+				return list[i].toIssue(), nil
+			}
+		}
+
+		if len(list) < githubPageSize {
+			return nil, nil
+		}
+	}
+}
+
+// CreateIssue implements IssueTracker.
+func (t *GitHubTracker) CreateIssue(ctx context.Context, title, body string) (*Issue, error) {
+	request := map[string]interface{}{
+		"title":  title,
+		"body":   body,
+		"labels": []string{t.Label},
+	}
+
+	var created githubIssue
+	if err := t.do(ctx, http.MethodPost, "/repos/"+t.Repo+"/issues", request, &created); err != nil {
+		return nil, err
+	}
+
+	return created.toIssue(), nil
+}
+
+// UpdateIssue implements IssueTracker.
+func (t *GitHubTracker) UpdateIssue(ctx context.Context, issue *Issue, body string) error {
+	request := map[string]interface{}{"body": body}
+
+	var updated githubIssue
+	if err := t.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", t.Repo, issue.ID), request, &updated); err != nil {
+		return err
+	}
+
+	issue.Body = updated.Body
+	return nil
+}
+
+func (t *GitHubTracker) do(ctx context.Context, method, path string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitHub request %s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,218 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// EnvironmentFailure lists the runs a test failed in, in an environment.
+type EnvironmentFailure struct {
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Runs are the consecutive failing runs, most recent first
+	Runs []int
+	// Maintainer is the maintainer of the test in the most recent run
+	Maintainer string
+	// Description is the test description in the most recent run
+	Description string
+	// FailureMessage is the message test failed with in the most recent run
+	FailureMessage string
+	// FailureLocation is where test failed in the most recent run
+	FailureLocation string
+	// Artifacts are the artifacts of the test in the most recent run
+	Artifacts []es_utils.Artifact
+}
+
+// PersistentFailure is a test which failed in all the latest runs of at
+// least one environment.
+type PersistentFailure struct {
+	// Name is the test name
+	Name string
+	// Environments are the environments test is persistently failing in
+	Environments []EnvironmentFailure
+}
+
+// FindPersistentFailures returns tests which failed in each of the latest
+// threshold runs of at least one of the environments envs. Tests for which
+// excluded returns true are ignored.
+func FindPersistentFailures(ctx context.Context, logger logr.Logger,
+	envs []string, threshold int,
+	excluded func(test string) bool,
+) ([]PersistentFailure, error) {
+	failures := make(map[string]*PersistentFailure)
+
+	for _, env := range envs {
+		runs, err := es_utils.GetAvailableRuns(ctx, env, threshold, logger)
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(runs)))
+		if len(runs) > threshold {
+			runs = runs[:threshold]
+		}
+		if len(runs) < threshold {
+			logger.Info(fmt.Sprintf("Only %d run(s) available in %s, %d needed", len(runs), env, threshold))
+			continue
+		}
+
+		results, err := es_utils.ListResultsForRuns(ctx, logger, env, runs)
+		if err != nil {
+			return nil, err
+		}
+
+		failed := make(map[string]map[int]*es_utils.Result)
+		for i := range results {
+			r := &results[i]
+			if r.Result != "failed" || excluded(r.Name) {
+				continue
+			}
+			if failed[r.Name] == nil {
+				failed[r.Name] = make(map[int]*es_utils.Result)
+			}
+			failed[r.Name][r.Run] = r
+		}
+
+		for name, byRun := range failed {
+			if len(byRun) < len(runs) {
+				continue
+			}
+			latest := byRun[runs[0]]
+			f, ok := failures[name]
+			if !ok {
+				f = &PersistentFailure{Name: name}
+				failures[name] = f
+			}
+			f.Environments = append(f.Environments, EnvironmentFailure{
+				Environment:     env,
+				Runs:            runs,
+				Maintainer:      latest.Maintainer,
+				Description:     latest.Description,
+				FailureMessage:  latest.FailureMessage,
+				FailureLocation: latest.FailureLocation,
+				Artifacts:       latest.Artifacts,
+			})
+		}
+	}
+
+	list := make([]PersistentFailure, 0, len(failures))
+	for _, f := range failures {
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+// Title returns the title of the issue tracking f.
+func (f *PersistentFailure) Title() string {
+	return fmt.Sprintf("Persistent e2e failure: %s", f.Name)
+}
+
+// Body returns the markdown description of the issue tracking f.
+func (f *PersistentFailure) Body() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Test `%s` failed in every one of the latest runs listed below.\n", f.Name)
+	for i := range f.Environments {
+		e := &f.Environments[i]
+		runs := make([]string, len(e.Runs))
+		for j := range e.Runs {
+			runs[j] = fmt.Sprint(e.Runs[j])
+		}
+		maintainer := e.Maintainer
+		if maintainer == "" {
+			maintainer = "unknown"
+		}
+
+		fmt.Fprintf(&sb, "\n### %s\n\n", e.Environment)
+		fmt.Fprintf(&sb, "- **Failing runs:** %s\n", strings.Join(runs, ", "))
+		fmt.Fprintf(&sb, "- **Maintainer:** %s\n", maintainer)
+		if e.Description != "" {
+			fmt.Fprintf(&sb, "- **Description:** %s\n", e.Description)
+		}
+		if e.FailureLocation != "" {
+			fmt.Fprintf(&sb, "- **Failure location:** `%s`\n", e.FailureLocation)
+		}
+		if len(e.Artifacts) > 0 {
+			links := make([]string, len(e.Artifacts))
+			for j := range e.Artifacts {
+				links[j] = fmt.Sprintf("[%s](%s)", e.Artifacts[j].Name, e.Artifacts[j].URL)
+			}
+			fmt.Fprintf(&sb, "- **Artifacts (run %d):** %s\n", e.Runs[0], strings.Join(links, ", "))
+		}
+		if e.FailureMessage != "" {
+			fmt.Fprintf(&sb, "\nLatest failure message:\n\n```\n%s\n```\n", strings.TrimSpace(e.FailureMessage))
+		}
+	}
+	sb.WriteString("\n_Filed by `e2e_result triage file-issues`. This description is updated on every run._\n")
+	return sb.String()
+}
+
+// Action is what FileIssues did for a persistent failure.
+type Action string
+
+const (
+	// Created means a new issue was opened
+	Created Action = "created"
+	// Updated means the description of an open issue was updated
+	Updated Action = "updated"
+	// Unchanged means an open issue already had the up to date description
+	Unchanged Action = "unchanged"
+)
+
+// Outcome is the result of filing a persistent failure.
+type Outcome struct {
+	// Test is the test name
+	Test string
+	// Action is what was done
+	Action Action
+	// URL links to the issue. Empty in dry run when issue would be created.
+	URL string
+}
+
+// FileIssues opens an issue for each failure with no open issue, and
+// updates open issues whose description changed. If dryRun is set, tracker is
+// only queried.
+func FileIssues(ctx context.Context, tracker IssueTracker,
+	failures []PersistentFailure, dryRun bool,
+) ([]Outcome, error) {
+	outcomes := make([]Outcome, 0, len(failures))
+	for i := range failures {
+		f := &failures[i]
+		title, body := f.Title(), f.Body()
+
+		issue, err := tracker.FindOpenIssue(ctx, title)
+		if err != nil {
+			return outcomes, err
+		}
+
+		switch {
+		case issue == nil:
+			outcome := Outcome{Test: f.Name, Action: Created}
+			if !dryRun {
+				issue, err = tracker.CreateIssue(ctx, title, body)
+				if err != nil {
+					return outcomes, err
+				}
+				outcome.URL = issue.URL
+			}
+			outcomes = append(outcomes, outcome)
+		case issue.Body == body:
+			outcomes = append(outcomes, Outcome{Test: f.Name, Action: Unchanged, URL: issue.URL})
+		default:
+			if !dryRun {
+				if err := tracker.UpdateIssue(ctx, issue, body); err != nil {
+					return outcomes, err
+				}
+			}
+			outcomes = append(outcomes, Outcome{Test: f.Name, Action: Updated, URL: issue.URL})
+		}
+	}
+
+	return outcomes, nil
+}
@@ -0,0 +1,95 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeTracker is an in-memory IssueTracker.
+type fakeTracker struct {
+	issues  []*Issue
+	created int
+	updated int
+}
+
+func (f *fakeTracker) FindOpenIssue(_ context.Context, title string) (*Issue, error) {
+	for _, issue := range f.issues {
+		if issue.Title == title {
+			copied := *issue
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeTracker) CreateIssue(_ context.Context, title, body string) (*Issue, error) {
+	f.created++
+	issue := &Issue{ID: len(f.issues) + 1, Title: title, Body: body}
+	issue.URL = fmt.Sprintf("https://github.com/example/e2e/issues/%d", issue.ID)
+	f.issues = append(f.issues, issue)
+	return issue, nil
+}
+
+func (f *fakeTracker) UpdateIssue(_ context.Context, issue *Issue, body string) error {
+	f.updated++
+	for _, i := range f.issues {
+		if i.ID == issue.ID {
+			i.Body = body
+			return nil
+		}
+	}
+	return fmt.Errorf("issue %d not found", issue.ID)
+}
+
+func testFailures(runs ...int) []PersistentFailure {
+	return []PersistentFailure{{
+		Name: "upgrade",
+		Environments: []EnvironmentFailure{{Environment: "vcs", Runs: runs, Maintainer: "alice",
+			FailureMessage: "timed out", FailureLocation: "upgrade_test.go:87"}},
+	}}
+}
+
+func TestFileIssues(t *testing.T) {
+	ctx := context.Background()
+	tracker := &fakeTracker{}
+	url := "https://github.com/example/e2e/issues/1"
+
+	tests := []struct {
+		name     string
+		failures []PersistentFailure
+		dryRun   bool
+		want     Outcome
+	}{
+		{name: "dry run", failures: testFailures(2927, 2926, 2925), dryRun: true,
+			want: Outcome{Test: "upgrade", Action: Created}},
+		{name: "first triage", failures: testFailures(2927, 2926, 2925),
+			want: Outcome{Test: "upgrade", Action: Created, URL: url}},
+		{name: "second triage", failures: testFailures(2927, 2926, 2925),
+			want: Outcome{Test: "upgrade", Action: Unchanged, URL: url}},
+		{name: "new failing run", failures: testFailures(2928, 2927, 2926),
+			want: Outcome{Test: "upgrade", Action: Updated, URL: url}},
+		{name: "after update", failures: testFailures(2928, 2927, 2926),
+			want: Outcome{Test: "upgrade", Action: Unchanged, URL: url}},
+	}
+	for _, tt := range tests {
+		outcomes, err := FileIssues(ctx, tracker, tt.failures, tt.dryRun)
+		if err != nil {
+			t.Fatalf("%s: FileIssues failed: %v", tt.name, err)
+		}
+		if want := []Outcome{tt.want}; !reflect.DeepEqual(outcomes, want) {
+			t.Errorf("%s: outcomes are %+v, want %+v", tt.name, outcomes, want)
+		}
+	}
+
+	if tracker.created != 1 || len(tracker.issues) != 1 {
+		t.Errorf("%d issue(s) created, want 1", tracker.created)
+	}
+	if tracker.updated != 1 {
+		t.Errorf("%d issue(s) updated, want 1", tracker.updated)
+	}
+	if body := testFailures(2928, 2927, 2926)[0].Body(); tracker.issues[0].Body != body {
+		t.Errorf("issue body is %q, want %q", tracker.issues[0].Body, body)
+	}
+}
@@ -0,0 +1,29 @@
+package issues
+
+import (
+	"context"
+)
+
+// Issue is an issue in a tracker.
+type Issue struct {
+	// ID identifies the issue in the tracker (e.g. GitHub issue number)
+	ID int
+	// Title is the issue title
+	Title string
+	// Body is the issue description
+	Body string
+	// URL is a link to the issue
+	URL string
+}
+
+// IssueTracker is implemented by issue trackers persistent failures are
+// filed in.
+type IssueTracker interface {
+	// FindOpenIssue returns the open issue with exactly this title, nil if
+	// there is none.
+	FindOpenIssue(ctx context.Context, title string) (*Issue, error)
+	// CreateIssue opens a new issue.
+	CreateIssue(ctx context.Context, title, body string) (*Issue, error)
+	// UpdateIssue replaces the description of an existing issue.
+	UpdateIssue(ctx context.Context, issue *Issue, body string) error
+}
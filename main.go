@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
@@ -12,6 +14,9 @@ import (
 	docopt "github.com/docopt/docopt-go"
 
 	"github.com/gianlucam76/cs-e2e-result/commands"
+	"github.com/gianlucam76/cs-e2e-result/commands/cmdutil"
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
 )
 
 func main() {
@@ -26,9 +31,31 @@ func main() {
 	e2e_result [options] <command> [<args>...]
 
 	show          Display information on e2e results
+	gate          Evaluate a quality policy against a run
+	serve         Expose e2e results over an HTTP API
+	export        Export e2e results
+	import        Import an archive written by export
+	notify        Send a run summary to a webhook or by email
+	triage        File issues for persistent failures
+	quarantine    Manage quarantined tests
+	bisect        Locate the run a test started failing in
+	push          Store e2e data
+	admin         Manage Elasticsearch indices
+
+Exit codes:
+  0             Success.
+  1             Invalid command line.
+  2             Elasticsearch could not be reached.
+  3             Query matched failed tests (with --fail-on-failures).
+  4             Any other error.
+  5             Gate policy violated.
 
 Options:
-  -h --help     Show this screen.
+  -h --help                 Show this screen.
+     --es-retries=<int>     Number of retries when Elasticsearch is unreachable (default is 3)
+     --es-backoff=<dur>     Initial wait between retries, doubled at every retry (default is 500ms)
+     --quarantine=<file>    File listing quarantined tests (default is quarantine.yaml)
+     --store=<file>         Store configuration: backend (elasticsearch7, or elasticsearch8 and opensearch in compatibility mode), URL and credentials
 
 Description:
   The e2e_result command line tool is used to display e2e results.
@@ -49,7 +76,39 @@ Description:
 				strings.Join(os.Args[1:], " "),
 			)
 		}
-		os.Exit(1)
+		os.Exit(cmdutil.ExitUsage)
+	}
+
+	retryPolicy := es_utils.DefaultRetryPolicy
+	if passedRetries := opts["--es-retries"]; passedRetries != nil {
+		retryPolicy.MaxRetries, err = strconv.Atoi(passedRetries.(string))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --es-retries value: %v\n", err)
+			os.Exit(cmdutil.ExitUsage)
+		}
+	}
+	if passedBackoff := opts["--es-backoff"]; passedBackoff != nil {
+		retryPolicy.InitialBackoff, err = time.ParseDuration(passedBackoff.(string))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --es-backoff value: %v\n", err)
+			os.Exit(cmdutil.ExitUsage)
+		}
+	}
+	es_utils.SetRetryPolicy(retryPolicy)
+
+	if passedStore := opts["--store"]; passedStore != nil {
+		config, err := es_utils.LoadStoreConfig(passedStore.(string))
+		if err == nil {
+			err = es_utils.SetStore(config)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --store value: %v\n", err)
+			os.Exit(cmdutil.ExitUsage)
+		}
+	}
+
+	if passedQuarantine := opts["--quarantine"]; passedQuarantine != nil {
+		quarantine.SetFile(passedQuarantine.(string))
 	}
 
 	if opts["<command>"] != nil {
@@ -60,12 +119,33 @@ Description:
 		switch command {
 		case "show":
 			err = commands.Show(ctx, args)
+		case "gate":
+			err = commands.Gate(ctx, args)
+		case "serve":
+			err = commands.Serve(ctx, args)
+		case "export":
+			err = commands.Export(ctx, args)
+		case "import":
+			err = commands.Import(ctx, args)
+		case "notify":
+			err = commands.Notify(ctx, args)
+		case "triage":
+			err = commands.Triage(ctx, args)
+		case "quarantine":
+			err = commands.Quarantine(ctx, args)
+		case "bisect":
+			err = commands.Bisect(ctx, args)
+		case "push":
+			err = commands.Push(ctx, args)
+		case "admin":
+			err = commands.Admin(ctx, args)
 		default:
-			err = fmt.Errorf("unknown command: %q\n%s", command, doc)
+			err = &cmdutil.UsageError{Args: args, Err: fmt.Errorf("unknown command: %q\n%s", command, doc)}
 		}
 
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 		}
+		os.Exit(cmdutil.ExitCode(err))
 	}
 }
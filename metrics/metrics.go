@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Sample is a single value of a metric.
+type Sample struct {
+	// Labels are label name/value pairs
+	Labels [][2]string
+	// Value is the sample value
+	Value float64
+}
+
+// Family is a metric with all its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+// add adds a sample to f. A sample with the same labels as an earlier one
+// is merged into it, keeping the larger value.
+func (f *Family) add(labels [][2]string, value float64) {
+	key := formatLabels(labels)
+	for i := range f.Samples {
+		if formatLabels(f.Samples[i].Labels) == key {
+			if value > f.Samples[i].Value {
+				f.Samples[i].Value = value
+			}
+			return
+		}
+	}
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+var environments = []string{"vcs", "ucs"}
+
+// Collect builds gauges from the newest run, per environment, of the
+// results, reports and usage indices. Samples are labelled by environment,
+// not by run: e2e_latest_run tells which run they come from.
+func Collect(ctx context.Context, logger logr.Logger) ([]Family, error) {
+	latestRun := Family{Name: "e2e_latest_run",
+		Help: "Newest run id for which results were collected."}
+	testResult := Family{Name: "e2e_test_result",
+		Help: "Result of a test in the newest run: 1 passed, 0 failed, -1 skipped."}
+	testDuration := Family{Name: "e2e_test_duration_minutes",
+		Help: "Duration of a test in the newest run."}
+	reportDuration := Family{Name: "e2e_report_duration_minutes",
+		Help: "Longest duration of a report in the newest run."}
+	memoryMax := Family{Name: "e2e_pod_memory_max_kib",
+		Help: "Max memory used by a pod in the newest run."}
+	memoryLimit := Family{Name: "e2e_pod_memory_limit_kib",
+		Help: "Memory limit of a pod in the newest run (0 if unset)."}
+	cpuMax := Family{Name: "e2e_pod_cpu_max_millicores",
+		Help: "Max CPU used by a pod in the newest run."}
+	cpuLimit := Family{Name: "e2e_pod_cpu_limit_millicores",
+		Help: "CPU limit of a pod in the newest run (0 if unset)."}
+
+	for _, env := range environments {
+		vcs, ucs := env == "vcs", env == "ucs"
+
+		newest, err := es_utils.GetResults(ctx, logger, es_utils.ResultFilter{Environment: env, Max: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(newest) > 0 {
+			run := newest[0].Run
+			latestRun.add([][2]string{{"env", env}}, float64(run))
+
+			results, err := es_utils.GetResults(ctx, logger,
+				es_utils.ResultFilter{Environment: env, Run: strconv.Itoa(run), Max: es_utils.MaxQuerySize})
+			if err != nil {
+				return nil, err
+			}
+			for i := range results {
+				labels := [][2]string{{"test", results[i].Name}, {"env", env}}
+				testResult.add(labels, resultValue(results[i].Result))
+				testDuration.add(labels, results[i].DurationInMinutes)
+			}
+		}
+
+		newestReports, err := es_utils.GetReports(ctx, logger, "", "", "", "", vcs, ucs, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(newestReports) > 0 {
+			reports, err := es_utils.GetReports(ctx, logger, strconv.Itoa(newestReports[0].Run), "", "", "",
+				vcs, ucs, es_utils.MaxQuerySize)
+			if err != nil {
+				return nil, err
+			}
+			for i := range reports {
+				r := &reports[i]
+				reportDuration.add([][2]string{{"type", r.Type}, {"subtype", r.SubType}, {"name", r.Name}, {"env", env}},
+					r.DurationInMinutes)
+			}
+		}
+
+		newestUsage, err := es_utils.GetUsageReports(ctx, logger, "", "", vcs, ucs, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(newestUsage) > 0 {
+			usage, err := es_utils.GetUsageReports(ctx, logger, strconv.Itoa(newestUsage[0].Run), "",
+				vcs, ucs, es_utils.MaxQuerySize)
+			if err != nil {
+				return nil, err
+			}
+			for i := range usage {
+				u := &usage[i]
+				labels := [][2]string{{"pod", u.Name}, {"env", env}}
+				memoryMax.add(labels, float64(u.Memory))
+				memoryLimit.add(labels, float64(u.MemoryLimit))
+				cpuMax.add(labels, float64(u.CPU))
+				cpuLimit.add(labels, float64(u.CPULimit))
+			}
+		}
+	}
+
+	return []Family{latestRun, testResult, testDuration, reportDuration,
+		memoryMax, memoryLimit, cpuMax, cpuLimit}, nil
+}
+
+func resultValue(result string) float64 {
+	switch result {
+	case "passed":
+		return 1
+	case "skipped":
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Write writes families in Prometheus text exposition format, samples
+// sorted by labels. Nothing is written if two samples of a family have the
+// same labels.
+func Write(w io.Writer, families []Family) error {
+	lines := make([]map[string]float64, len(families))
+	for i := range families {
+		f := &families[i]
+		lines[i] = make(map[string]float64, len(f.Samples))
+		for _, s := range f.Samples {
+			series := f.Name + formatLabels(s.Labels)
+			if _, ok := lines[i][series]; ok {
+				return fmt.Errorf("metric %s has more than one sample %s", f.Name, series)
+			}
+			lines[i][series] = s.Value
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for i := range families {
+		f := &families[i]
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, f.Help)
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", f.Name)
+
+		keys := make([]string, 0, len(lines[i]))
+		for k := range lines[i] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(bw, "%s %s\n", k, strconv.FormatFloat(lines[i][k], 'g', -1, 64))
+		}
+	}
+
+	return bw.Flush()
+}
+
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf("%s=\"%s\"", l[0], labelValueEscaper.Replace(l[1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// labelValueEscaper escapes label values as required by the exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
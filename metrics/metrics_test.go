@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	families := []Family{
+		{Name: "e2e_latest_run", Help: "Newest run id for which results were collected.", Samples: []Sample{
+			{Labels: [][2]string{{"env", "vcs"}}, Value: 2927},
+			{Labels: [][2]string{{"env", "ucs"}}, Value: 1044},
+		}},
+		{Name: "e2e_report_duration_minutes", Help: "Longest duration of a report in the newest run.", Samples: []Sample{
+			{Labels: [][2]string{{"type", "deploy"}, {"subtype", "cluster"}, {"name", `C:\clusters`}, {"env", "vcs"}},
+				Value: 12.5},
+			{Labels: [][2]string{{"type", "deploy"}, {"subtype", "cluster"}, {"name", "the \"big\"\none"}, {"env", "vcs"}},
+				Value: 0.25},
+		}},
+		{Name: "e2e_up", Help: "Always one.", Samples: []Sample{{Value: 1}}},
+		{Name: "e2e_empty", Help: "No samples."},
+	}
+
+	want := `# HELP e2e_latest_run Newest run id for which results were collected.
+# TYPE e2e_latest_run gauge
+e2e_latest_run{env="ucs"} 1044
+e2e_latest_run{env="vcs"} 2927
+# HELP e2e_report_duration_minutes Longest duration of a report in the newest run.
+# TYPE e2e_report_duration_minutes gauge
+e2e_report_duration_minutes{type="deploy",subtype="cluster",name="C:\\clusters",env="vcs"} 12.5
+e2e_report_duration_minutes{type="deploy",subtype="cluster",name="the \"big\"\none",env="vcs"} 0.25
+# HELP e2e_up Always one.
+# TYPE e2e_up gauge
+e2e_up 1
+# HELP e2e_empty No samples.
+# TYPE e2e_empty gauge
+`
+	var b bytes.Buffer
+	if err := Write(&b, families); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Write wrote\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteDuplicateSamples(t *testing.T) {
+	labels := [][2]string{{"pod", "controller"}, {"env", "vcs"}}
+	families := []Family{
+		{Name: "e2e_latest_run", Help: "Newest run id.", Samples: []Sample{{Labels: [][2]string{{"env", "vcs"}}, Value: 2927}}},
+		{Name: "e2e_pod_memory_max_kib", Help: "Max memory.", Samples: []Sample{
+			{Labels: labels, Value: 900},
+			{Labels: labels, Value: 800},
+		}},
+	}
+
+	var b bytes.Buffer
+	err := Write(&b, families)
+	if err == nil || !strings.Contains(err.Error(), `e2e_pod_memory_max_kib{pod="controller",env="vcs"}`) {
+		t.Errorf("Write error = %v, want duplicate sample reported", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Write wrote %q", b.String())
+	}
+}
+
+func TestAdd(t *testing.T) {
+	f := Family{Name: "e2e_report_duration_minutes"}
+	f.add([][2]string{{"type", "deploy"}, {"env", "vcs"}}, 10)
+	f.add([][2]string{{"type", "deploy"}, {"env", "ucs"}}, 5)
+	f.add([][2]string{{"type", "deploy"}, {"env", "vcs"}}, 30)
+	f.add([][2]string{{"type", "deploy"}, {"env", "vcs"}}, 20)
+
+	if len(f.Samples) != 2 {
+		t.Fatalf("got %d samples, want 2: %v", len(f.Samples), f.Samples)
+	}
+	if f.Samples[0].Value != 30 || f.Samples[1].Value != 5 {
+		t.Errorf("samples are %v, want the largest value per labels", f.Samples)
+	}
+}
@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templatesFS, "templates/email.html.tmpl"))
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templatesFS, "templates/email.txt.tmpl"))
+)
+
+// EmailConfig describes how a digest is sent.
+type EmailConfig struct {
+	// SMTPAddr is the SMTP server host:port
+	SMTPAddr string
+	// From is the sender. Defaults to e2e-result@<SMTP host>
+	From string
+	// To are the recipients
+	To []string
+	// StartTLS, if set, upgrades the connection with STARTTLS
+	StartTLS bool
+	// Username, if set, is used to authenticate (PLAIN)
+	Username string
+	// Password is used with Username
+	Password string
+}
+
+// Validate verifies config and sets defaults.
+func (c *EmailConfig) Validate() error {
+	host, _, err := net.SplitHostPort(c.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", c.SMTPAddr, err)
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	if c.From == "" {
+		c.From = "e2e-result@" + host
+	}
+	return nil
+}
+
+// emailData is the data email templates are executed with.
+type emailData struct {
+	Subject   string
+	Summaries []*Summary
+}
+
+// emailSubject returns the subject of a digest for summaries.
+func emailSubject(summaries []*Summary) string {
+	parts := make([]string, len(summaries))
+	for i, s := range summaries {
+		status := "passed"
+		if s.Failed > 0 {
+			status = fmt.Sprintf("%d failed", s.Failed)
+		}
+		parts[i] = fmt.Sprintf("%s run %d %s", s.Environment, s.Run, status)
+	}
+	return "e2e digest: " + strings.Join(parts, ", ")
+}
+
+// BuildEmail renders the digest of summaries as a multipart/alternative
+// message (plain text and HTML) ready to be sent.
+func BuildEmail(config *EmailConfig, summaries []*Summary) ([]byte, error) {
+	data := emailData{Subject: emailSubject(summaries), Summaries: summaries}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", data.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{contentType: "text/plain", body: text.Bytes()},
+		{contentType: "text/html", body: html.Bytes()},
+	} {
+		fmt.Fprintf(&msg, "--%s\r\n", boundary)
+		fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		fmt.Fprintf(&msg, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		w := quotedprintable.NewWriter(&msg)
+		if _, err := w.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&msg, "\r\n")
+	}
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+
+	return msg.Bytes(), nil
+}
+
+// SendEmail sends msg through the configured SMTP server.
+func SendEmail(config *EmailConfig, msg []byte) error {
+	host, _, err := net.SplitHostPort(config.SMTPAddr)
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.Dial(config.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", config.SMTPAddr, err)
+	}
+	defer c.Close()
+
+	if config.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not offer STARTTLS", config.SMTPAddr)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("STARTTLS with %s failed: %w", config.SMTPAddr, err)
+		}
+	}
+
+	if config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", config.Username, config.Password, host)); err != nil {
+			return fmt.Errorf("authentication with %s failed: %w", config.SMTPAddr, err)
+		}
+	}
+
+	if err := c.Mail(config.From); err != nil {
+		return err
+	}
+	for _, to := range config.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
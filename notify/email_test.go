@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what an SMTP stand-in received.
+type smtpSession struct {
+	from     string
+	rcpts    []string
+	data     string
+	startTLS bool
+}
+
+// smtpServer is an in-process SMTP stand-in accepting every message. It does
+// not offer STARTTLS. Connections are handled one at a time.
+type smtpServer struct {
+	listener net.Listener
+	sessions []*smtpSession
+	done     chan struct{}
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpServer{listener: l, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() {
+		l.Close()
+		<-s.done
+	})
+	return s
+}
+
+func (s *smtpServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	session := &smtpSession{}
+	s.sessions = append(s.sessions, session)
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "MAIL":
+			session.from = pathOf(line)
+			reply("250 OK")
+		case "RCPT":
+			session.rcpts = append(session.rcpts, pathOf(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			session.data = data.String()
+			reply("250 OK")
+		case "STARTTLS":
+			session.startTLS = true
+			reply("502 Command not implemented")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// pathOf returns the address between angle brackets of a MAIL or RCPT
+// command.
+func pathOf(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// received stops the server and returns the sessions it handled.
+func (s *smtpServer) received() []*smtpSession {
+	s.listener.Close()
+	<-s.done
+	return s.sessions
+}
+
+func TestSendEmail(t *testing.T) {
+	server := newSMTPServer(t)
+	config := &EmailConfig{SMTPAddr: server.addr(), To: []string{"dev@example.com", "qa@example.com"}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if config.From != "e2e-result@127.0.0.1" {
+		t.Errorf("default From is %q", config.From)
+	}
+
+	msg, err := BuildEmail(config, testSummaries())
+	if err != nil {
+		t.Fatalf("BuildEmail failed: %v", err)
+	}
+	if err := SendEmail(config, msg); err != nil {
+		t.Fatalf("SendEmail failed: %v", err)
+	}
+
+	sessions := server.received()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d SMTP sessions, want 1", len(sessions))
+	}
+	session := sessions[0]
+	if session.from != config.From {
+		t.Errorf("MAIL FROM is %q, want %q", session.from, config.From)
+	}
+	if strings.Join(session.rcpts, ",") != "dev@example.com,qa@example.com" {
+		t.Errorf("RCPT TO are %v", session.rcpts)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("received message is invalid: %v", err)
+	}
+	wantHeaders := map[string]string{
+		"From":         "e2e-result@127.0.0.1",
+		"To":           "dev@example.com, qa@example.com",
+		"Subject":      "e2e digest: vcs run 2927 1 failed",
+		"Mime-Version": "1.0",
+	}
+	for header, want := range wantHeaders {
+		if got := m.Header.Get(header); got != want {
+			t.Errorf("header %s is %q, want %q", header, got, want)
+		}
+	}
+	if _, err := m.Header.Date(); err != nil {
+		t.Errorf("invalid Date header: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type is %q", m.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(m.Body, params["boundary"])
+	for _, want := range []struct {
+		contentType string
+		contains    []string
+	}{
+		{contentType: "text/plain", contains: []string{
+			"e2e vcs run 2927 failed: 10 passed, 1 failed, 2 skipped",
+			"New failures (vs run 2926):",
+			"  - upgrade (alice)",
+		}},
+		{contentType: "text/html", contains: []string{"<h2>e2e digest: vcs run 2927 1 failed</h2>", "upgrade"}},
+	} {
+		part, err := parts.NextRawPart()
+		if err != nil {
+			t.Fatalf("missing %s part: %v", want.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType+"; charset=utf-8" {
+			t.Errorf("part Content-Type is %q, want %s", got, want.contentType)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("invalid quoted-printable %s part: %v", want.contentType, err)
+		}
+		for _, s := range want.contains {
+			if !strings.Contains(string(body), s) {
+				t.Errorf("%s part does not contain %q:\n%s", want.contentType, s, body)
+			}
+		}
+	}
+	if _, err := parts.NextRawPart(); err != io.EOF {
+		t.Errorf("want exactly two parts, got %v", err)
+	}
+}
+
+func TestSendEmailStartTLSNotOffered(t *testing.T) {
+	server := newSMTPServer(t)
+	config := &EmailConfig{SMTPAddr: server.addr(), To: []string{"dev@example.com"}, StartTLS: true}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	msg, err := BuildEmail(config, testSummaries())
+	if err != nil {
+		t.Fatalf("BuildEmail failed: %v", err)
+	}
+	err = SendEmail(config, msg)
+	if err == nil || !strings.Contains(err.Error(), "does not offer STARTTLS") {
+		t.Fatalf("SendEmail error is %v, want STARTTLS not offered", err)
+	}
+
+	for _, session := range server.received() {
+		if session.startTLS || session.data != "" {
+			t.Errorf("message sent in clear or STARTTLS attempted: %+v", session)
+		}
+	}
+}
+
+func TestEmailConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  EmailConfig
+		wantErr bool
+	}{
+		{name: "valid", config: EmailConfig{SMTPAddr: "smtp.example.com:25", To: []string{"a@example.com"}}},
+		{name: "missing port", config: EmailConfig{SMTPAddr: "smtp.example.com", To: []string{"a@example.com"}}, wantErr: true},
+		{name: "no recipient", config: EmailConfig{SMTPAddr: "smtp.example.com:25"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"sort"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/quarantine"
+)
+
+const (
+	// DefaultNearLimitPercent is the usage, as percentage of the limit, above
+	// which a pod is reported as near its limit.
+	DefaultNearLimitPercent = 80
+	// DefaultRegressionPercent is the duration increase, vs previous run,
+	// above which a test is reported as a duration regression.
+	DefaultRegressionPercent = 50
+	// maxSlowestReports is the number of slowest reports in a summary
+	maxSlowestReports = 5
+	// minRegressionMinutes is the minimum duration increase reported as a
+	// regression, so that short tests do not cause noise.
+	minRegressionMinutes = 1
+)
+
+// Failure is a test which failed in the run.
+type Failure struct {
+	// Name is the test name
+	Name string
+	// Maintainer is the test maintainer
+	Maintainer string
+}
+
+// PodUsage is a pod whose usage is near its limit.
+type PodUsage struct {
+	// Name identifies the pod
+	Name string
+	// Resource is either Memory or CPU
+	Resource string
+	// Used is the max usage seen (Ki for memory, m for CPU)
+	Used int64
+	// Limit is the pod limit (Ki for memory, m for CPU)
+	Limit int64
+	// Unit is Ki for memory and m for CPU
+	Unit string
+	// Percent is Used as percentage of Limit
+	Percent float64
+}
+
+// Regression is a test which took significantly longer than in the previous run.
+type Regression struct {
+	// Name is the test name
+	Name string
+	// PreviousMinutes is the test duration in the previous run
+	PreviousMinutes float64
+	// CurrentMinutes is the test duration in the run
+	CurrentMinutes float64
+}
+
+// Summary is the content of a notification for a run in an environment.
+type Summary struct {
+	// Environment represents the environment where e2e ran, i.e UCS or VCS
+	Environment string
+	// Run is the sanity run id
+	Run int
+	// PreviousRun is the run NewFailures are computed against. Zero if none.
+	PreviousRun int
+	Passed      int
+	Failed      int
+	Skipped     int
+	// NewFailures are tests which failed in Run but not in PreviousRun
+	NewFailures []Failure
+	// QuarantinedFailures are quarantined tests which failed in Run. They
+	// are not counted in Failed nor reported in NewFailures.
+	QuarantinedFailures []string
+	// DurationRegressions are tests which took significantly longer than in PreviousRun
+	DurationRegressions []Regression
+	// SlowestReports are the longest reports of the run
+	SlowestReports []es_utils.Report
+	// PodsNearLimit are the pods whose usage exceeds the near limit threshold
+	PodsNearLimit []PodUsage
+}
+
+// BuildSummary builds a Summary from data. Pods using more than
+// nearLimitPercent of their limit are reported, as well as tests whose
+// duration increased by more than regressionPercent since previous run.
+// Results of quarantined tests are removed from data.
+func BuildSummary(data *es_utils.RunData, q *quarantine.List, nearLimitPercent, regressionPercent float64) *Summary {
+	s := &Summary{
+		Environment: data.Environment,
+		Run:         data.Run,
+		PreviousRun: data.PreviousRun,
+	}
+
+	s.QuarantinedFailures = data.ExcludeTests(q.IsQuarantined)
+	sort.Strings(s.QuarantinedFailures)
+
+	s.Passed, s.Failed, s.Skipped = data.Counts()
+
+	for _, r := range data.NewFailures() {
+		s.NewFailures = append(s.NewFailures, Failure{Name: r.Name, Maintainer: r.Maintainer})
+	}
+	sort.Slice(s.NewFailures, func(i, j int) bool { return s.NewFailures[i].Name < s.NewFailures[j].Name })
+
+	previousDuration := make(map[string]float64)
+	for i := range data.PreviousResults {
+		if data.PreviousResults[i].Result == "passed" {
+			previousDuration[data.PreviousResults[i].Name] = data.PreviousResults[i].DurationInMinutes
+		}
+	}
+	for i := range data.Results {
+		r := &data.Results[i]
+		previous, ok := previousDuration[r.Name]
+		if !ok || r.Result != "passed" || previous <= 0 {
+			continue
+		}
+		if r.DurationInMinutes-previous >= minRegressionMinutes &&
+			(r.DurationInMinutes-previous)*100/previous > regressionPercent {
+			s.DurationRegressions = append(s.DurationRegressions,
+				Regression{Name: r.Name, PreviousMinutes: previous, CurrentMinutes: r.DurationInMinutes})
+		}
+	}
+	sort.Slice(s.DurationRegressions, func(i, j int) bool {
+		return s.DurationRegressions[i].CurrentMinutes-s.DurationRegressions[i].PreviousMinutes >
+			s.DurationRegressions[j].CurrentMinutes-s.DurationRegressions[j].PreviousMinutes
+	})
+
+	reports := append([]es_utils.Report(nil), data.Reports...)
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].DurationInMinutes > reports[j].DurationInMinutes
+	})
+	if len(reports) > maxSlowestReports {
+		reports = reports[:maxSlowestReports]
+	}
+	s.SlowestReports = reports
+
+	for i := range data.UsageReports {
+		u := &data.UsageReports[i]
+		for _, p := range []PodUsage{
+			{Name: u.Name, Resource: "Memory", Used: u.Memory, Limit: u.MemoryLimit, Unit: "Ki"},
+			{Name: u.Name, Resource: "CPU", Used: u.CPU, Limit: u.CPULimit, Unit: "m"},
+		} {
+			if p.Limit <= 0 {
+				continue
+			}
+			p.Percent = float64(p.Used) * 100 / float64(p.Limit)
+			if p.Percent >= nearLimitPercent {
+				s.PodsNearLimit = append(s.PodsNearLimit, p)
+			}
+		}
+	}
+	sort.Slice(s.PodsNearLimit, func(i, j int) bool { return s.PodsNearLimit[i].Percent > s.PodsNearLimit[j].Percent })
+
+	return s
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WebhookFormat is the payload format expected by a webhook.
+type WebhookFormat string
+
+const (
+	// SlackFormat is a Slack incoming webhook payload using blocks
+	SlackFormat WebhookFormat = "slack"
+	// TeamsFormat is a Microsoft Teams payload carrying an adaptive card
+	TeamsFormat WebhookFormat = "teams"
+)
+
+const webhookTimeout = 30 * time.Second
+
+// maxSectionText is the longest text of a section. Slack rejects section
+// blocks whose text is longer than 3000 characters.
+const maxSectionText = 3000
+
+// ParseWebhookFormat validates format. An empty format means SlackFormat.
+func ParseWebhookFormat(format string) (WebhookFormat, error) {
+	switch WebhookFormat(format) {
+	case "", SlackFormat:
+		return SlackFormat, nil
+	case TeamsFormat:
+		return TeamsFormat, nil
+	default:
+		return "", fmt.Errorf("unknown webhook format %q (valid values are slack and teams)", format)
+	}
+}
+
+// Payload returns the webhook payload describing summaries.
+func Payload(format WebhookFormat, summaries []*Summary) interface{} {
+	if format == TeamsFormat {
+		return teamsPayload(summaries)
+	}
+	return slackPayload(summaries)
+}
+
+// PostWebhook POSTs payload, as JSON, to url.
+func PostWebhook(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook %s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// Title returns a one line description of a summary.
+func (s *Summary) Title() string {
+	status := "passed"
+	if s.Failed > 0 {
+		status = "failed"
+	}
+	return fmt.Sprintf("e2e %s run %d %s: %d passed, %d failed, %d skipped",
+		s.Environment, s.Run, status, s.Passed, s.Failed, s.Skipped)
+}
+
+// newFailuresLines returns one line per new failure.
+func (s *Summary) newFailuresLines() []string {
+	lines := make([]string, len(s.NewFailures))
+	for i, f := range s.NewFailures {
+		maintainer := f.Maintainer
+		if maintainer == "" {
+			maintainer = "no maintainer"
+		}
+		lines[i] = fmt.Sprintf("%s (%s)", f.Name, maintainer)
+	}
+	return lines
+}
+
+// durationRegressionsLines returns one line per duration regression.
+func (s *Summary) durationRegressionsLines() []string {
+	lines := make([]string, len(s.DurationRegressions))
+	for i, r := range s.DurationRegressions {
+		lines[i] = fmt.Sprintf("%s: %.1f min -> %.1f min", r.Name, r.PreviousMinutes, r.CurrentMinutes)
+	}
+	return lines
+}
+
+// slowestReportsLines returns one line per slow report.
+func (s *Summary) slowestReportsLines() []string {
+	lines := make([]string, len(s.SlowestReports))
+	for i := range s.SlowestReports {
+		r := &s.SlowestReports[i]
+		reportType := r.Type
+		if r.SubType != "" {
+			reportType = fmt.Sprintf("%s/%s", r.Type, r.SubType)
+		}
+		lines[i] = fmt.Sprintf("%s %s: %.1f min", reportType, r.Name, r.DurationInMinutes)
+	}
+	return lines
+}
+
+// podsNearLimitLines returns one line per pod near its limit.
+func (s *Summary) podsNearLimitLines() []string {
+	lines := make([]string, len(s.PodsNearLimit))
+	for i, p := range s.PodsNearLimit {
+		lines[i] = fmt.Sprintf("%s %s: %d%s/%d%s (%.0f%%)", p.Name, p.Resource, p.Used, p.Unit, p.Limit, p.Unit, p.Percent)
+	}
+	return lines
+}
+
+// section is a titled list of lines of a summary.
+type section struct {
+	title string
+	lines []string
+}
+
+// bulletList returns head followed by one line per entry of lines, each
+// starting with bullet. If the text would be longer than maxSectionText,
+// the lines which do not fit are replaced by an "…and N more" line.
+func bulletList(head, bullet string, lines []string) string {
+	var b strings.Builder
+	b.WriteString(head)
+	length := utf8.RuneCountInString(head)
+	for i, line := range lines {
+		item := bullet + line
+		if b.Len() > 0 {
+			item = "\n" + item
+		}
+		reserved := 0
+		if i < len(lines)-1 {
+			reserved = utf8.RuneCountInString(moreLine(bullet, len(lines)-i-1))
+		}
+		if length+utf8.RuneCountInString(item)+reserved > maxSectionText {
+			b.WriteString(moreLine(bullet, len(lines)-i))
+			break
+		}
+		b.WriteString(item)
+		length += utf8.RuneCountInString(item)
+	}
+	return b.String()
+}
+
+// moreLine returns the line standing for n lines left out of a bullet list.
+func moreLine(bullet string, n int) string {
+	return fmt.Sprintf("\n%s…and %d more", bullet, n)
+}
+
+// sections returns the sections describing the summary details.
+func (s *Summary) sections() []section {
+	newFailuresTitle := "New failures"
+	if s.PreviousRun != 0 {
+		newFailuresTitle = fmt.Sprintf("New failures (vs run %d)", s.PreviousRun)
+	}
+	return []section{
+		{title: newFailuresTitle, lines: s.newFailuresLines()},
+		{title: "Quarantined failures (not counted)", lines: s.QuarantinedFailures},
+		{title: "Duration regressions", lines: s.durationRegressionsLines()},
+		{title: "Slowest reports", lines: s.slowestReportsLines()},
+		{title: "Pods near limits", lines: s.podsNearLimitLines()},
+	}
+}
+
+func slackPayload(summaries []*Summary) map[string]interface{} {
+	titles := make([]string, 0, len(summaries))
+	blocks := make([]interface{}, 0)
+	for _, s := range summaries {
+		titles = append(titles, s.Title())
+		blocks = append(blocks,
+			map[string]interface{}{
+				"type": "header",
+				"text": map[string]interface{}{"type": "plain_text", "text": s.Title()},
+			})
+		for _, section := range s.sections() {
+			if len(section.lines) == 0 {
+				continue
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{
+					"type": "mrkdwn",
+					"text": bulletList(fmt.Sprintf("*%s*", section.title), "• ", section.lines),
+				},
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "divider"})
+	}
+
+	return map[string]interface{}{
+		"text":   strings.Join(titles, "\n"),
+		"blocks": blocks,
+	}
+}
+
+func teamsPayload(summaries []*Summary) map[string]interface{} {
+	body := make([]interface{}, 0)
+	for _, s := range summaries {
+		color := "Good"
+		if s.Failed > 0 {
+			color = "Attention"
+		}
+		body = append(body,
+			map[string]interface{}{
+				"type": "TextBlock", "text": s.Title(), "weight": "Bolder", "size": "Medium",
+				"color": color, "wrap": true,
+			},
+			map[string]interface{}{
+				"type": "FactSet",
+				"facts": []interface{}{
+					map[string]string{"title": "Passed", "value": fmt.Sprint(s.Passed)},
+					map[string]string{"title": "Failed", "value": fmt.Sprint(s.Failed)},
+					map[string]string{"title": "Skipped", "value": fmt.Sprint(s.Skipped)},
+				},
+			})
+		for _, section := range s.sections() {
+			if len(section.lines) == 0 {
+				continue
+			}
+			body = append(body,
+				map[string]interface{}{"type": "TextBlock", "text": section.title, "weight": "Bolder", "wrap": true},
+				map[string]interface{}{"type": "TextBlock", "text": bulletList("", "- ", section.lines), "wrap": true})
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
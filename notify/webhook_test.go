@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func testSummaries() []*Summary {
+	return []*Summary{
+		{
+			Environment: "vcs",
+			Run:         2927,
+			PreviousRun: 2926,
+			Passed:      10,
+			Failed:      1,
+			Skipped:     2,
+			NewFailures: []Failure{{Name: "upgrade", Maintainer: "alice"}},
+		},
+	}
+}
+
+// webhookRequest is a request received by a webhook stand-in.
+type webhookRequest struct {
+	method      string
+	contentType string
+	body        map[string]interface{}
+}
+
+// newWebhookServer returns a webhook stand-in replying with status and
+// recording the requests it receives.
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, *[]webhookRequest) {
+	t.Helper()
+	requests := &[]webhookRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		req := webhookRequest{method: r.Method, contentType: r.Header.Get("Content-Type")}
+		if err := json.Unmarshal(data, &req.body); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+		*requests = append(*requests, req)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("invalid_payload\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestPostWebhookSlack(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusOK)
+
+	if err := PostWebhook(context.Background(), server.URL, Payload(SlackFormat, testSummaries())); err != nil {
+		t.Fatalf("PostWebhook failed: %v", err)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.method != http.MethodPost {
+		t.Errorf("method is %s, want POST", req.method)
+	}
+	if req.contentType != "application/json" {
+		t.Errorf("Content-Type is %q, want application/json", req.contentType)
+	}
+
+	want := "e2e vcs run 2927 failed: 10 passed, 1 failed, 2 skipped"
+	if req.body["text"] != want {
+		t.Errorf("text is %q, want %q", req.body["text"], want)
+	}
+	blocks, ok := req.body["blocks"].([]interface{})
+	if !ok || len(blocks) != 3 {
+		t.Fatalf("want header, new failures section and divider blocks, got %v", req.body["blocks"])
+	}
+	for i, blockType := range []string{"header", "section", "divider"} {
+		if got := blocks[i].(map[string]interface{})["type"]; got != blockType {
+			t.Errorf("block %d has type %v, want %s", i, got, blockType)
+		}
+	}
+	section := blocks[1].(map[string]interface{})["text"].(map[string]interface{})["text"].(string)
+	if want := "*New failures (vs run 2926)*\n• upgrade (alice)"; section != want {
+		t.Errorf("section is %q, want %q", section, want)
+	}
+}
+
+func TestPostWebhookTeams(t *testing.T) {
+	server, requests := newWebhookServer(t, http.StatusAccepted)
+
+	if err := PostWebhook(context.Background(), server.URL, Payload(TeamsFormat, testSummaries())); err != nil {
+		t.Fatalf("PostWebhook failed: %v", err)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.contentType != "application/json" {
+		t.Errorf("Content-Type is %q, want application/json", req.contentType)
+	}
+	if req.body["type"] != "message" {
+		t.Errorf("type is %v, want message", req.body["type"])
+	}
+
+	attachments := req.body["attachments"].([]interface{})
+	if len(attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(attachments))
+	}
+	attachment := attachments[0].(map[string]interface{})
+	if attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("contentType is %v", attachment["contentType"])
+	}
+	card := attachment["content"].(map[string]interface{})
+	if card["type"] != "AdaptiveCard" {
+		t.Errorf("card type is %v, want AdaptiveCard", card["type"])
+	}
+
+	body := card["body"].([]interface{})
+	title := body[0].(map[string]interface{})
+	if title["color"] != "Attention" {
+		t.Errorf("title color is %v, want Attention for a failed run", title["color"])
+	}
+	facts := body[1].(map[string]interface{})["facts"].([]interface{})
+	for i, want := range []string{"10", "1", "2"} {
+		if got := facts[i].(map[string]interface{})["value"]; got != want {
+			t.Errorf("fact %d is %v, want %s", i, got, want)
+		}
+	}
+}
+
+// moreRe matches the line replacing the lines left out of a section.
+var moreRe = regexp.MustCompile(`\n(• |- )…and (\d+) more$`)
+
+// checkTruncated verifies text fits in a section and, with the lines left
+// out, lists all failures.
+func checkTruncated(t *testing.T, format WebhookFormat, text string, failures int) {
+	t.Helper()
+	if n := utf8.RuneCountInString(text); n > maxSectionText {
+		t.Errorf("%s: section has %d characters, want at most %d", format, n, maxSectionText)
+	}
+	m := moreRe.FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("%s: section does not end with the number of lines left out: %q", format, text)
+	}
+	more, _ := strconv.Atoi(m[2])
+	if listed := strings.Count(text, "\n"+m[1]) - 1; listed+more != failures {
+		t.Errorf("%s: section lists %d failures and %d more, want %d in all", format, listed, more, failures)
+	}
+}
+
+func TestPayloadManyFailures(t *testing.T) {
+	summaries := testSummaries()
+	summaries[0].NewFailures = make([]Failure, 500)
+	for i := range summaries[0].NewFailures {
+		summaries[0].NewFailures[i] = Failure{Name: fmt.Sprintf("test-%03d-of-the-upgrade-suite", i), Maintainer: "alice"}
+	}
+
+	slack := Payload(SlackFormat, summaries).(map[string]interface{})
+	section := slack["blocks"].([]interface{})[1].(map[string]interface{})["text"].(map[string]interface{})["text"].(string)
+	checkTruncated(t, SlackFormat, section, 500)
+
+	teams := Payload(TeamsFormat, summaries).(map[string]interface{})
+	card := teams["attachments"].([]interface{})[0].(map[string]interface{})["content"].(map[string]interface{})
+	lines := card["body"].([]interface{})[3].(map[string]interface{})["text"].(string)
+	// The first line has no leading newline to count.
+	checkTruncated(t, TeamsFormat, "\n"+lines, 500)
+
+	// A short list is not truncated.
+	if text := bulletList("*Failures*", "• ", []string{"a", "b"}); text != "*Failures*\n• a\n• b" {
+		t.Errorf("bulletList() = %q", text)
+	}
+}
+
+func TestPostWebhookError(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusBadRequest)
+
+	err := PostWebhook(context.Background(), server.URL, Payload(SlackFormat, testSummaries()))
+	if err == nil {
+		t.Fatal("PostWebhook succeeded on a 400 response")
+	}
+	for _, want := range []string{"400 Bad Request", "invalid_payload"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestParseWebhookFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    WebhookFormat
+		wantErr bool
+	}{
+		{format: "", want: SlackFormat},
+		{format: "slack", want: SlackFormat},
+		{format: "teams", want: TeamsFormat},
+		{format: "discord", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWebhookFormat(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWebhookFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWebhookFormat(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
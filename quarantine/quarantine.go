@@ -0,0 +1,196 @@
+package quarantine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the quarantine file used unless SetFile is called.
+const DefaultFile = "quarantine.yaml"
+
+// dateLayout is the layout of date-only expiries.
+const dateLayout = "2006-01-02"
+
+var file = DefaultFile
+
+// SetFile overrides the quarantine file used by all commands.
+func SetFile(path string) {
+	file = path
+}
+
+// File returns the quarantine file used by all commands.
+func File() string {
+	return file
+}
+
+// Entry is a quarantined test.
+type Entry struct {
+	// Test is the name of the quarantined test
+	Test string `json:"test"`
+	// Reason explains why test is quarantined
+	Reason string `json:"reason"`
+	// Owner is who is responsible for fixing the test
+	Owner string `json:"owner"`
+	// Added is when the test was quarantined
+	Added time.Time `json:"added"`
+	// Expires is when the quarantine ends. Nil means never. In the file it
+	// is either a timestamp or a date, which lasts until the end of the day.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// UnmarshalJSON decodes an entry, accepting a date (2006-01-02) as expiry.
+// Unknown fields are rejected.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	type plainEntry Entry
+	var raw struct {
+		plainEntry
+		Expires *string `json:"expires,omitempty"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	*e = Entry(raw.plainEntry)
+	if raw.Expires == nil {
+		return nil
+	}
+	expires, err := ParseDate(*raw.Expires)
+	if err != nil {
+		if expires, err = time.Parse(time.RFC3339, *raw.Expires); err != nil {
+			return fmt.Errorf("invalid expiry %q of test %s", *raw.Expires, e.Test)
+		}
+	}
+	e.Expires = &expires
+	return nil
+}
+
+// ParseDate parses a date-only expiry (2006-01-02). The quarantine lasts
+// until the end of that day (UTC).
+func ParseDate(value string) (time.Time, error) {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+// Expired returns true if entry quarantine ended before now.
+func (e *Entry) Expired(now time.Time) bool {
+	return e.Expires != nil && e.Expires.Before(now)
+}
+
+// List is the content of a quarantine file.
+type List struct {
+	Entries []Entry `json:"entries"`
+
+	path string
+}
+
+// Load reads the quarantine file at path. A missing file is an empty list.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine file %s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// LoadActive reads the configured quarantine file and logs entries
+// which expired. Expired entries are not honoured.
+func LoadActive(logger logr.Logger) (*List, error) {
+	l, err := Load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range l.ExpiredEntries(time.Now()) {
+		logger.Info(fmt.Sprintf("Quarantine of test %s (owner %s) expired on %s and is ignored",
+			e.Test, e.Owner, e.Expires.Format(dateLayout)))
+	}
+
+	return l, nil
+}
+
+// Save writes the list back to the file it was loaded from.
+func (l *List) Save() error {
+	sort.Slice(l.Entries, func(i, j int) bool { return l.Entries[i].Test < l.Entries[j].Test })
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.path, data, 0o644)
+}
+
+// Add quarantines a test. An existing entry for the same test is replaced;
+// in that case true is returned.
+func (l *List) Add(e Entry) bool {
+	for i := range l.Entries {
+		if l.Entries[i].Test == e.Test {
+			l.Entries[i] = e
+			return true
+		}
+	}
+	l.Entries = append(l.Entries, e)
+	return false
+}
+
+// Remove removes a test from quarantine. It returns false if test was not
+// quarantined.
+func (l *List) Remove(test string) bool {
+	for i := range l.Entries {
+		if l.Entries[i].Test == test {
+			l.Entries = append(l.Entries[:i], l.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// IsQuarantined returns true if test has a quarantine entry which has not
+// expired. A nil list quarantines nothing.
+func (l *List) IsQuarantined(test string) bool {
+	if l == nil {
+		return false
+	}
+
+	now := time.Now()
+	for i := range l.Entries {
+		if l.Entries[i].Test == test && !l.Entries[i].Expired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiredEntries returns entries which expired before now.
+func (l *List) ExpiredEntries(now time.Time) []Entry {
+	expired := make([]Entry, 0)
+	for i := range l.Entries {
+		if l.Entries[i].Expired(now) {
+			expired = append(expired, l.Entries[i])
+		}
+	}
+	return expired
+}
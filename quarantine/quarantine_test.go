@@ -0,0 +1,149 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeList writes content to a quarantine file in a temporary directory
+// and returns its path.
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quarantine.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write quarantine file: %v", err)
+	}
+	return path
+}
+
+func TestExpired(t *testing.T) {
+	date, err := ParseDate("2026-10-19")
+	if err != nil {
+		t.Fatalf("ParseDate failed: %v", err)
+	}
+	entry := Entry{Test: "upgrade", Expires: &date}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{now: time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), want: false},
+		{now: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), want: false},
+		{now: time.Date(2026, 10, 19, 23, 59, 0, 0, time.UTC), want: false},
+		{now: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), want: true},
+	}
+	for _, tt := range tests {
+		if got := entry.Expired(tt.now); got != tt.want {
+			t.Errorf("Expired(%s) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+
+	if (&Entry{Test: "upgrade"}).Expired(time.Now()) {
+		t.Errorf("entry with no expiry expired")
+	}
+}
+
+func TestIsQuarantined(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	l := &List{Entries: []Entry{
+		{Test: "upgrade"},
+		{Test: "backup", Expires: &future},
+		{Test: "restore", Expires: &past},
+	}}
+
+	tests := []struct {
+		test string
+		want bool
+	}{
+		{test: "upgrade", want: true},
+		{test: "backup", want: true},
+		{test: "restore", want: false},
+		{test: "install", want: false},
+	}
+	for _, tt := range tests {
+		if got := l.IsQuarantined(tt.test); got != tt.want {
+			t.Errorf("IsQuarantined(%s) = %v, want %v", tt.test, got, tt.want)
+		}
+	}
+
+	var none *List
+	if none.IsQuarantined("upgrade") {
+		t.Errorf("nil list quarantines upgrade")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	content := `entries:
+- test: upgrade
+  reason: infra flake
+  owner: bob
+  added: 2026-10-01T10:00:00Z
+  expires: 2026-10-19
+- test: backup
+  owner: alice
+  added: 2026-10-02T10:00:00Z
+  expires: 2026-10-30T12:00:00Z
+- test: restore
+  owner: alice
+  added: 2026-10-03T10:00:00Z
+`
+	l, err := Load(writeList(t, content))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(l.Entries) != 3 {
+		t.Fatalf("loaded %d entries, want 3", len(l.Entries))
+	}
+
+	wantExpires := []time.Time{
+		time.Date(2026, 10, 19, 23, 59, 59, 0, time.UTC),
+		time.Date(2026, 10, 30, 12, 0, 0, 0, time.UTC),
+	}
+	for i, want := range wantExpires {
+		if e := l.Entries[i]; e.Expires == nil || !e.Expires.Equal(want) {
+			t.Errorf("entry %s expires %v, want %s", e.Test, e.Expires, want)
+		}
+	}
+	if e := l.Entries[2]; e.Expires != nil || e.Owner != "alice" || e.Added.IsZero() {
+		t.Errorf("entry %s is %+v", e.Test, e)
+	}
+
+	// Entries survive a save and load.
+	if err := l.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := Load(l.path)
+	if err != nil {
+		t.Fatalf("Load of saved list failed: %v", err)
+	}
+	if len(saved.Entries) != 3 || !saved.Entries[2].Expires.Equal(wantExpires[0]) {
+		t.Errorf("saved list is %+v", saved.Entries)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown field", content: "entries:\n- test: upgrade\n  team: storage\n", wantErr: "unknown field"},
+		{name: "invalid expiry", content: "entries:\n- test: upgrade\n  expires: next week\n",
+			wantErr: `invalid expiry "next week" of test upgrade`},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeList(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Load error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// A missing file is an empty list.
+	l, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(l.Entries) != 0 {
+		t.Errorf("Load of missing file = (%v, %v), want an empty list", l, err)
+	}
+}
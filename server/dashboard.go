@@ -0,0 +1,23 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// dashboardFS contains the static web dashboard. It only relies on the
+// /api/v1 endpoints and has no external dependency.
+//
+//go:embed dashboard
+var dashboardFS embed.FS
+
+// dashboardHandler serves the web dashboard.
+func dashboardHandler() http.Handler {
+	content, err := fs.Sub(dashboardFS, "dashboard")
+	if err != nil {
+		// dashboard directory is embedded at build time
+		panic(err)
+	}
+	return http.FileServer(http.FS(content))
+}
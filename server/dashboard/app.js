@@ -0,0 +1,218 @@
+// e2e results dashboard. Data comes from the /api/v1 endpoints exposed by
+// `e2e_result serve`.
+(function () {
+  "use strict";
+
+  const palette = ["#1e88e5", "#e53935", "#43a047", "#fb8c00", "#8e24aa",
+    "#00acc1", "#6d4c41", "#c0ca33", "#d81b60", "#546e7a"];
+
+  const $ = (id) => document.getElementById(id);
+
+  let state = { env: "vcs", runs: [], reports: [] };
+
+  async function api(path, params) {
+    const query = new URLSearchParams(params).toString();
+    const resp = await fetch("api/v1/" + path + "?" + query);
+    const body = await resp.json();
+    if (!resp.ok) {
+      throw new Error(body.error || resp.statusText);
+    }
+    return body || [];
+  }
+
+  function setStatus(msg) {
+    $("status").textContent = msg;
+  }
+
+  function el(tag, attrs, text) {
+    const ns = ["svg", "line", "polyline", "circle", "text", "rect", "path", "title"].includes(tag) ?
+      "http://www.w3.org/2000/svg" : "http://www.w3.org/1999/xhtml";
+    const e = document.createElementNS(ns, tag);
+    for (const [k, v] of Object.entries(attrs || {})) {
+      e.setAttribute(k, v);
+    }
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  // lineChart draws one polyline per series. Each series is
+  // {name, points: [{x, y}], dashed}. X values are run ids.
+  function lineChart(container, series, xs, yLabel) {
+    container.innerHTML = "";
+    const all = series.flatMap((s) => s.points);
+    if (all.length === 0) {
+      container.textContent = "No data";
+      return;
+    }
+
+    const width = 900, height = 320, left = 60, right = 220, top = 10, bottom = 40;
+    const maxY = Math.max(...all.map((p) => p.y)) * 1.1 || 1;
+    const xPos = (x) => left + (xs.length === 1 ? 0 :
+      xs.indexOf(x) * (width - left - right) / (xs.length - 1));
+    const yPos = (y) => height - bottom - y * (height - top - bottom) / maxY;
+
+    const svg = el("svg", { width: width, height: height });
+    svg.appendChild(el("line", { x1: left, y1: height - bottom, x2: width - right, y2: height - bottom, stroke: "#999" }));
+    svg.appendChild(el("line", { x1: left, y1: top, x2: left, y2: height - bottom, stroke: "#999" }));
+
+    for (let i = 0; i <= 4; i++) {
+      const v = maxY * i / 4;
+      svg.appendChild(el("text", { x: left - 5, y: yPos(v) + 4, "text-anchor": "end" }, v.toFixed(1)));
+      svg.appendChild(el("line", { x1: left, y1: yPos(v), x2: width - right, y2: yPos(v), stroke: "#eee" }));
+    }
+    const step = Math.ceil(xs.length / 15);
+    xs.forEach((x, i) => {
+      if (i % step === 0) {
+        svg.appendChild(el("text", { x: xPos(x), y: height - bottom + 15, "text-anchor": "middle" }, x));
+      }
+    });
+    svg.appendChild(el("text", { x: left, y: height - 5 }, "run"));
+    svg.appendChild(el("text", { x: 5, y: top + 10 }, yLabel));
+
+    series.forEach((s, i) => {
+      const color = s.color || palette[i % palette.length];
+      const points = s.points.slice().sort((a, b) => a.x - b.x);
+      svg.appendChild(el("polyline", {
+        points: points.map((p) => xPos(p.x) + "," + yPos(p.y)).join(" "),
+        fill: "none", stroke: color, "stroke-width": 2,
+        "stroke-dasharray": s.dashed ? "5,4" : "",
+      }));
+      points.forEach((p) => {
+        const c = el("circle", { cx: xPos(p.x), cy: yPos(p.y), r: 3, fill: color });
+        c.appendChild(el("title", {}, s.name + " run " + p.x + ": " + p.y.toFixed(2)));
+        svg.appendChild(c);
+      });
+      svg.appendChild(el("rect", { x: width - right + 15, y: top + i * 18, width: 10, height: 10, fill: color }));
+      svg.appendChild(el("text", { x: width - right + 30, y: top + i * 18 + 9 }, s.name));
+    });
+
+    container.appendChild(svg);
+  }
+
+  function renderMatrix(runs, resultsByRun) {
+    const container = $("matrix");
+    container.innerHTML = "";
+
+    const tests = new Map();
+    resultsByRun.forEach((results) => results.forEach((r) => {
+      tests.set(r.name, tests.get(r.name) || r.serial);
+    }));
+    if (tests.size === 0) {
+      container.textContent = "No results";
+      return;
+    }
+
+    const table = el("table", { class: "matrix" });
+    const header = el("tr");
+    header.appendChild(el("th", {}, "test \\ run"));
+    runs.forEach((run) => header.appendChild(el("th", {}, run)));
+    table.appendChild(header);
+
+    Array.from(tests.keys()).sort().forEach((name) => {
+      const row = el("tr");
+      row.appendChild(el("td", { class: "name" }, name + (tests.get(name) ? "*" : "")));
+      runs.forEach((run, i) => {
+        const r = resultsByRun[i].find((r) => r.name === name);
+        const td = el("td");
+        const cell = el("span", { class: "cell " + (r ? r.result : "missing") });
+        cell.title = r ? name + " run " + run + ": " + r.result + " (" +
+          r.durationInMinutes.toFixed(2) + " min)" : name + " not run in " + run;
+        td.appendChild(cell);
+        row.appendChild(td);
+      });
+      table.appendChild(row);
+    });
+
+    container.appendChild(table);
+  }
+
+  function renderReports() {
+    const type = $("report-type").value;
+    const runs = state.runs.slice().reverse();
+    const bySubType = new Map();
+    state.reports.filter((r) => r.type === type && runs.includes(r.run)).forEach((r) => {
+      const subType = r.subType || "(none)";
+      if (!bySubType.has(subType)) {
+        bySubType.set(subType, new Map());
+      }
+      const perRun = bySubType.get(subType);
+      perRun.set(r.run, (perRun.get(r.run) || []).concat(r.durationInMinutes));
+    });
+
+    const series = Array.from(bySubType.entries()).map(([subType, perRun]) => ({
+      name: subType,
+      points: Array.from(perRun.entries()).map(([run, ds]) => ({
+        x: run, y: ds.reduce((a, b) => a + b, 0) / ds.length,
+      })),
+    }));
+    lineChart($("reports-chart"), series, runs, "average minutes");
+  }
+
+  async function renderUsage() {
+    const pod = $("pod").value;
+    if (!pod) {
+      $("usage-chart").textContent = "No usage reports";
+      return;
+    }
+    const resource = $("resource").value;
+    const runs = state.runs.slice().reverse();
+    const usage = (await api("usage", { env: state.env, pod: pod, max: 1000 }))
+      .filter((u) => runs.includes(u.run));
+
+    const used = resource === "memory" ? (u) => u.memory : (u) => u.cpu;
+    const limit = resource === "memory" ? (u) => u.memoryLimit : (u) => u.cpuLimit;
+    const series = [{ name: "max used", points: usage.map((u) => ({ x: u.run, y: used(u) })) }];
+    if (usage.some((u) => limit(u) > 0)) {
+      series.push({ name: "limit", dashed: true, color: "#e53935",
+        points: usage.filter((u) => limit(u) > 0).map((u) => ({ x: u.run, y: limit(u) })) });
+    }
+    lineChart($("usage-chart"), series, runs, resource === "memory" ? "Ki" : "m");
+  }
+
+  function fillSelect(select, values) {
+    const current = select.value;
+    select.innerHTML = "";
+    values.forEach((v) => select.appendChild(el("option", { value: v }, v)));
+    if (values.includes(current)) {
+      select.value = current;
+    }
+  }
+
+  async function refresh() {
+    state.env = $("env").value;
+    const max = Math.max(1, Math.min(100, parseInt($("runs").value, 10) || 20));
+    setStatus("Loading...");
+    try {
+      const runs = (await api("runs", { env: state.env, max: max })).map((r) => r.run);
+      state.runs = runs.slice(0, max);
+      const ordered = state.runs.slice().reverse();
+
+      const resultsByRun = await Promise.all(ordered.map((run) =>
+        api("results", { env: state.env, run: run, max: 10000 })));
+      renderMatrix(ordered, resultsByRun);
+
+      state.reports = await api("reports", { env: state.env, max: 10000 });
+      fillSelect($("report-type"), Array.from(new Set(state.reports.map((r) => r.type))).sort());
+      renderReports();
+
+      const latest = state.runs.length > 0 ?
+        await api("usage", { env: state.env, run: state.runs[0], max: 10000 }) : [];
+      fillSelect($("pod"), Array.from(new Set(latest.map((u) => u.name))).sort());
+      await renderUsage();
+
+      setStatus("Updated " + new Date().toLocaleTimeString());
+    } catch (err) {
+      setStatus("Error: " + err.message);
+    }
+  }
+
+  $("refresh").addEventListener("click", refresh);
+  $("env").addEventListener("change", refresh);
+  $("report-type").addEventListener("change", renderReports);
+  $("pod").addEventListener("change", () => renderUsage().catch((err) => setStatus("Error: " + err.message)));
+  $("resource").addEventListener("change", () => renderUsage().catch((err) => setStatus("Error: " + err.message)));
+
+  refresh();
+})();
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+	"github.com/gianlucam76/cs-e2e-result/metrics"
+)
+
+const (
+	apiPrefix       = "/api/v1"
+	defaultMax      = 100
+	shutdownTimeout = 10 * time.Second
+)
+
+// Server exposes e2e results, reports, usage reports and runs over HTTP.
+type Server struct {
+	logger logr.Logger
+	mux    *http.ServeMux
+}
+
+// New returns a Server with all API endpoints registered.
+func New(logger logr.Logger) *Server {
+	s := &Server{
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc(apiPrefix+"/results", s.handleResults)
+	s.mux.HandleFunc(apiPrefix+"/reports", s.handleReports)
+	s.mux.HandleFunc(apiPrefix+"/usage", s.handleUsage)
+	s.mux.HandleFunc(apiPrefix+"/runs", s.handleRuns)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.mux.Handle("/", dashboardHandler())
+
+	return s
+}
+
+// Handler returns the HTTP handler serving all endpoints.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe serves requests on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info(fmt.Sprintf("Listening on %s", addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// badRequestError is returned when query parameters are invalid.
+type badRequestError struct {
+	msg string
+}
+
+func (e *badRequestError) Error() string {
+	return e.msg
+}
+
+// filters contains the query parameters shared by all endpoints.
+type filters struct {
+	vcs bool
+	ucs bool
+	run string
+	max int
+}
+
+// environment returns the environment selected by f, empty for both.
+func (f *filters) environment() string {
+	switch {
+	case f.vcs:
+		return "vcs"
+	case f.ucs:
+		return "ucs"
+	default:
+		return ""
+	}
+}
+
+func parseFilters(r *http.Request) (*filters, error) {
+	q := r.URL.Query()
+	f := &filters{run: q.Get("run"), max: defaultMax}
+
+	switch env := strings.ToLower(q.Get("env")); env {
+	case "":
+	case "vcs":
+		f.vcs = true
+	case "ucs":
+		f.ucs = true
+	default:
+		return nil, &badRequestError{msg: fmt.Sprintf("unknown env %q (valid values are vcs and ucs)", env)}
+	}
+
+	if f.run != "" {
+		if _, err := strconv.Atoi(f.run); err != nil {
+			return nil, &badRequestError{msg: fmt.Sprintf("invalid run %q", f.run)}
+		}
+	}
+
+	if max := q.Get("max"); max != "" {
+		var err error
+		f.max, err = strconv.Atoi(max)
+		if err != nil || f.max <= 0 || f.max > es_utils.MaxQuerySize {
+			return nil, &badRequestError{
+				msg: fmt.Sprintf("invalid max %q (must be in [1, %d])", max, es_utils.MaxQuerySize)}
+		}
+	}
+
+	return f, nil
+}
+
+// parseBool parses the boolean query parameter name. A missing parameter
+// is false.
+func parseBool(q url.Values, name string) (bool, error) {
+	value := q.Get(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &badRequestError{msg: fmt.Sprintf("invalid %s %q (must be true or false)", name, value)}
+	}
+	return b, nil
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, func(ctx context.Context, f *filters) (interface{}, error) {
+		q := r.URL.Query()
+		switch result := q.Get("result"); result {
+		case "", "passed", "failed", "skipped":
+		default:
+			return nil, &badRequestError{msg: fmt.Sprintf("unknown result %q (valid values are passed, failed and skipped)", result)}
+		}
+
+		serial, err := parseBool(q, "serial")
+		if err != nil {
+			return nil, err
+		}
+		parallel, err := parseBool(q, "parallel")
+		if err != nil {
+			return nil, err
+		}
+		if serial && parallel {
+			return nil, &badRequestError{msg: "serial and parallel cannot both be set"}
+		}
+
+		return es_utils.GetResults(ctx, s.logger, es_utils.ResultFilter{
+			Environment: f.environment(),
+			Run:         f.run,
+			Test:        q.Get("test"),
+			Maintainer:  q.Get("maintainer"),
+			Result:      q.Get("result"),
+			Serial:      serial,
+			Parallel:    parallel,
+			Max:         f.max,
+		})
+	})
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, func(ctx context.Context, f *filters) (interface{}, error) {
+		q := r.URL.Query()
+		return es_utils.GetReports(ctx, s.logger, f.run, q.Get("type"), q.Get("subtype"), q.Get("name"),
+			f.vcs, f.ucs, f.max)
+	})
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, func(ctx context.Context, f *filters) (interface{}, error) {
+		return es_utils.GetUsageReports(ctx, s.logger, f.run, r.URL.Query().Get("pod"), f.vcs, f.ucs, f.max)
+	})
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, func(ctx context.Context, f *filters) (interface{}, error) {
+		return es_utils.ListRuns(ctx, s.logger, f.vcs, f.ucs, f.max)
+	})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	families, err := metrics.Collect(r.Context(), s.logger)
+	if err != nil {
+		s.logger.Error(err, "Failed to collect metrics")
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.Write(w, families); err != nil {
+		s.logger.Error(err, "Failed to write metrics")
+	}
+}
+
+// serve parses filters, invokes query and writes its result, or error, as JSON.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request,
+	query func(ctx context.Context, f *filters) (interface{}, error),
+) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	f, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := query(r.Context(), f)
+	if err != nil {
+		s.logger.Error(err, "Request failed", "path", r.URL.Path)
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// statusFor maps an error to the HTTP status returned to clients.
+func statusFor(err error) int {
+	var badRequestErr *badRequestError
+	var connErr *es_utils.ConnectionError
+	var indexErr *es_utils.IndexNotFoundError
+	switch {
+	case errors.As(err, &badRequestErr):
+		return http.StatusBadRequest
+	case errors.As(err, &connErr):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &indexErr):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gianlucam76/cs-e2e-result/es_utils"
+)
+
+// Search responses of Elasticsearch 7. The results response carries the
+// run aggregation too, so that it answers both result and run queries.
+const (
+	resultsResponse = `{"took":3,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":2,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e","_type":"_doc","_id":"r1","_score":null,"_source":{"name":"upgrade","result":"failed","serial":true,"environment":"vcs","run":2927,"durationInMinutes":12.5},"sort":[2927]},
+{"_index":"cs_e2e","_type":"_doc","_id":"r2","_score":null,"_source":{"name":"backup","result":"failed","serial":true,"environment":"vcs","run":2927,"durationInMinutes":3},"sort":[2927]}]},
+"aggregations":{"run":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":2927,"doc_count":2},{"key":2926,"doc_count":5}]}}}`
+	reportsResponse = `{"took":1,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":1,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e_entries","_type":"_doc","_id":"p1","_score":null,"_source":{"type":"deploy","subType":"cluster","name":"small","environment":"ucs","run":1044,"durationInMinutes":20},"sort":[1044]}]}}`
+	usageResponse = `{"took":1,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},
+"hits":{"total":{"value":1,"relation":"eq"},"max_score":null,"hits":[
+{"_index":"cs_e2e_usage_entries","_type":"_doc","_id":"u1","_score":null,"_source":{"name":"controller","memory":900,"memoryLimit":1000,"environment":"vcs","run":2927},"sort":[2927]}]}}`
+	badQueryResponse = `{"error":{"root_cause":[{"type":"query_shard_exception","reason":"failed to create query"}],
+"type":"search_phase_execution_exception","reason":"all shards failed"},"status":400}`
+)
+
+// esStandIn is an Elasticsearch stand-in answering searches of an index
+// with a canned response. Indices with no response do not exist.
+type esStandIn struct {
+	responses map[string]string
+	// searchStatus, if set, makes searches fail with this status
+	searchStatus int
+
+	mu       sync.Mutex
+	searches map[string][]string
+}
+
+func (es *esStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	index := parts[0]
+	response, exists := es.responses[index]
+
+	switch {
+	case index == "":
+		_, _ = w.Write([]byte(`{"version":{"number":"7.17.9"},"tagline":"You Know, for Search"}`))
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(parts) == 2 && parts[1] == "_mapping":
+		fmt.Fprintf(w, `{%q:{"mappings":{"_meta":{"schemaVersion":%d},"properties":{}}}}`, index, es_utils.SchemaVersion)
+	case len(parts) == 2 && parts[1] == "_search":
+		body, _ := io.ReadAll(r.Body)
+		es.mu.Lock()
+		es.searches[index] = append(es.searches[index], string(body))
+		es.mu.Unlock()
+		if es.searchStatus != 0 {
+			w.WriteHeader(es.searchStatus)
+			_, _ = w.Write([]byte(badQueryResponse))
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// newStandIn starts es and configures the store to use it.
+func newStandIn(t *testing.T, es *esStandIn) *httptest.Server {
+	t.Helper()
+	es.searches = make(map[string][]string)
+	server := httptest.NewServer(es)
+	es_utils.SetRetryPolicy(es_utils.RetryPolicy{})
+	if err := es_utils.SetStore(&es_utils.StoreConfig{URL: server.URL}); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = es_utils.SetStore(&es_utils.StoreConfig{})
+		es_utils.SetRetryPolicy(es_utils.DefaultRetryPolicy)
+		server.Close()
+	})
+	return server
+}
+
+func allIndices() map[string]string {
+	return map[string]string{
+		"cs_e2e":               resultsResponse,
+		"cs_e2e_entries":       reportsResponse,
+		"cs_e2e_usage_entries": usageResponse,
+	}
+}
+
+// get sends a GET request for path to the server and decodes the JSON
+// response into v.
+func get(t *testing.T, path string, v interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	New(logr.Discard()).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("%s: Content-Type is %q, want application/json", path, got)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s: response is not JSON: %v", path, err)
+	}
+	return rec.Code
+}
+
+func TestHandlers(t *testing.T) {
+	es := &esStandIn{responses: allIndices()}
+	newStandIn(t, es)
+
+	tests := []struct {
+		path      string
+		index     string
+		wantQuery []string
+		wantJSON  string
+	}{
+		{path: "/api/v1/results?env=vcs&run=2927&result=failed&serial=true", index: "cs_e2e",
+			wantQuery: []string{`{"match":{"environment":{"query":"vcs"}}}`, `{"term":{"serial":true}}`,
+				`{"match":{"result":{"query":"failed"}}}`, `{"match":{"run":{"query":"2927"}}}`},
+			wantJSON: `[{"name":"upgrade","result":"failed","serial":true,"environment":"vcs","run":2927,"durationInMinutes":12.5},` +
+				`{"name":"backup","result":"failed","serial":true,"environment":"vcs","run":2927,"durationInMinutes":3}]`},
+		{path: "/api/v1/results?parallel=true&max=5", index: "cs_e2e",
+			wantQuery: []string{`{"term":{"serial":false}}`, `"size":5`}},
+		{path: "/api/v1/reports?env=ucs&type=deploy", index: "cs_e2e_entries",
+			wantJSON: `[{"type":"deploy","subType":"cluster","name":"small","environment":"ucs","run":1044,"durationInMinutes":20}]`},
+		{path: "/api/v1/usage?pod=controller", index: "cs_e2e_usage_entries",
+			wantJSON: `[{"name":"controller","memory":900,"memoryLimit":1000,"environment":"vcs","run":2927}]`},
+		{path: "/api/v1/runs?env=vcs", index: "cs_e2e",
+			wantQuery: []string{`"aggregations":{"run":{"terms":{"field":"run","order":[{"_key":"desc"}],"size":100}}}`},
+			wantJSON:  `[{"environment":"vcs","run":2927},{"environment":"vcs","run":2926}]`},
+	}
+	for _, tt := range tests {
+		var got []map[string]interface{}
+		if status := get(t, tt.path, &got); status != http.StatusOK {
+			t.Errorf("%s: status is %d, want 200", tt.path, status)
+			continue
+		}
+
+		es.mu.Lock()
+		searches := es.searches[tt.index]
+		es.searches[tt.index] = nil
+		es.mu.Unlock()
+		if len(searches) != 1 {
+			t.Errorf("%s: %d searches of %s, want 1", tt.path, len(searches), tt.index)
+			continue
+		}
+		for _, q := range tt.wantQuery {
+			if !strings.Contains(searches[0], q) {
+				t.Errorf("%s: query %s does not contain %s", tt.path, searches[0], q)
+			}
+		}
+
+		if tt.wantJSON == "" {
+			continue
+		}
+		var want []map[string]interface{}
+		if err := json.Unmarshal([]byte(tt.wantJSON), &want); err != nil {
+			t.Fatalf("invalid wantJSON: %v", err)
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d entries, want %d", tt.path, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			for k, v := range want[i] {
+				if got[i][k] != v {
+					t.Errorf("%s: entry %d has %s %v, want %v", tt.path, i, k, got[i][k], v)
+				}
+			}
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	missingReports := allIndices()
+	delete(missingReports, "cs_e2e_entries")
+
+	tests := []struct {
+		name        string
+		es          *esStandIn
+		unreachable bool
+		method      string
+		path        string
+		wantStatus  int
+		wantError   string
+	}{
+		{name: "unknown env", path: "/api/v1/results?env=prod", wantStatus: http.StatusBadRequest,
+			wantError: `unknown env "prod"`},
+		{name: "invalid run", path: "/api/v1/usage?run=latest", wantStatus: http.StatusBadRequest,
+			wantError: `invalid run "latest"`},
+		{name: "max out of range", path: "/api/v1/reports?max=0", wantStatus: http.StatusBadRequest,
+			wantError: `invalid max "0"`},
+		{name: "unknown result", path: "/api/v1/results?result=flaky", wantStatus: http.StatusBadRequest,
+			wantError: `unknown result "flaky"`},
+		{name: "invalid serial", path: "/api/v1/results?serial=yes", wantStatus: http.StatusBadRequest,
+			wantError: `invalid serial "yes"`},
+		{name: "serial and parallel", path: "/api/v1/results?serial=true&parallel=true", wantStatus: http.StatusBadRequest,
+			wantError: "serial and parallel cannot both be set"},
+		{name: "method not allowed", method: http.MethodPost, path: "/api/v1/runs",
+			wantStatus: http.StatusMethodNotAllowed, wantError: "method POST not allowed"},
+		{name: "missing index", es: &esStandIn{responses: missingReports}, path: "/api/v1/reports",
+			wantStatus: http.StatusNotFound, wantError: "cs_e2e_entries"},
+		{name: "failed query", es: &esStandIn{responses: allIndices(), searchStatus: http.StatusBadRequest},
+			path: "/api/v1/results", wantStatus: http.StatusInternalServerError, wantError: "search_phase_execution_exception"},
+		{name: "unreachable", es: &esStandIn{responses: allIndices()}, unreachable: true,
+			path: "/api/v1/usage", wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		if tt.es == nil {
+			tt.es = &esStandIn{responses: allIndices()}
+		}
+		server := newStandIn(t, tt.es)
+		if tt.unreachable {
+			server.Close()
+		}
+		if tt.method == "" {
+			tt.method = http.MethodGet
+		}
+
+		rec := httptest.NewRecorder()
+		New(logr.Discard()).Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status is %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s: response is not JSON: %v", tt.name, err)
+			continue
+		}
+		if !strings.Contains(body["error"], tt.wantError) {
+			t.Errorf("%s: error is %q, want %q", tt.name, body["error"], tt.wantError)
+		}
+	}
+}
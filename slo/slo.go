@@ -0,0 +1,125 @@
+package slo
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Status is the outcome of checking a duration against its threshold.
+type Status string
+
+const (
+	// StatusNone means no threshold matches the report
+	StatusNone Status = ""
+	// StatusOK means duration is below the warning level
+	StatusOK Status = "OK"
+	// StatusWarn means duration is within the warning level and the maximum
+	StatusWarn Status = "WARN"
+	// StatusBreach means duration exceeds the maximum
+	StatusBreach Status = "BREACH"
+)
+
+const defaultWarnPercent = 90
+
+// Threshold is the maximum duration allowed for a report type.
+type Threshold struct {
+	// Type is the report type
+	Type string `json:"type"`
+	// SubType is optional. If set, threshold only applies to reports with
+	// this subtype.
+	SubType string `json:"subType,omitempty"`
+	// Name is optional. If set, threshold only applies to reports with
+	// this name.
+	Name string `json:"name,omitempty"`
+	// MaxMinutes is the maximum allowed duration in minutes
+	MaxMinutes float64 `json:"maxMinutes"`
+}
+
+// Thresholds is the content of a thresholds file.
+type Thresholds struct {
+	// WarnPercent is the percentage of MaxMinutes above which a report is
+	// marked WARN (default is 90).
+	WarnPercent float64 `json:"warnPercent,omitempty"`
+	// Thresholds is the list of thresholds. When more than one matches a
+	// report, the most specific one (name, then subtype, then type) is used.
+	Thresholds []Threshold `json:"thresholds"`
+}
+
+// Load reads and validates a thresholds file.
+func Load(path string) (*Thresholds, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Thresholds{}
+	if err := yaml.UnmarshalStrict(data, t); err != nil {
+		return nil, fmt.Errorf("failed to parse thresholds %s: %w", path, err)
+	}
+
+	if t.WarnPercent == 0 {
+		t.WarnPercent = defaultWarnPercent
+	}
+	if t.WarnPercent < 0 || t.WarnPercent > 100 {
+		return nil, fmt.Errorf("thresholds %s: warnPercent must be in (0, 100]", path)
+	}
+
+	for i := range t.Thresholds {
+		if t.Thresholds[i].Type == "" {
+			return nil, fmt.Errorf("thresholds %s, entry %d: type is required", path, i+1)
+		}
+		if t.Thresholds[i].MaxMinutes <= 0 {
+			return nil, fmt.Errorf("thresholds %s, entry %d: maxMinutes must be positive", path, i+1)
+		}
+	}
+
+	return t, nil
+}
+
+// Find returns the most specific threshold matching a report, nil if none does.
+func (t *Thresholds) Find(reportType, subType, name string) *Threshold {
+	var best *Threshold
+	bestScore := -1
+	for i := range t.Thresholds {
+		th := &t.Thresholds[i]
+		if th.Type != reportType ||
+			(th.SubType != "" && th.SubType != subType) ||
+			(th.Name != "" && th.Name != name) {
+			continue
+		}
+
+		score := 0
+		if th.Name != "" {
+			score += 2
+		}
+		if th.SubType != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = th, score
+		}
+	}
+
+	return best
+}
+
+// Check returns the status of a report with the given duration and the
+// threshold used. Threshold is nil, and status StatusNone, if no threshold
+// matches the report.
+func (t *Thresholds) Check(reportType, subType, name string, durationInMinutes float64) (Status, *Threshold) {
+	th := t.Find(reportType, subType, name)
+	if th == nil {
+		return StatusNone, nil
+	}
+
+	switch {
+	case durationInMinutes > th.MaxMinutes:
+		return StatusBreach, th
+	case durationInMinutes >= th.MaxMinutes*t.WarnPercent/100:
+		return StatusWarn, th
+	default:
+		return StatusOK, th
+	}
+}
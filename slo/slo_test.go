@@ -0,0 +1,101 @@
+package slo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeThresholds writes content to a thresholds file in a temporary
+// directory and returns its path.
+func writeThresholds(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "slo.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write thresholds: %v", err)
+	}
+	return path
+}
+
+const testThresholds = `thresholds:
+- type: deploy
+  maxMinutes: 30
+- type: deploy
+  subType: cluster
+  maxMinutes: 20
+- type: deploy
+  name: big-cluster
+  maxMinutes: 60
+- type: deploy
+  subType: cluster
+  name: big-cluster
+  maxMinutes: 50
+`
+
+func TestCheck(t *testing.T) {
+	thresholds, err := Load(writeThresholds(t, testThresholds))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if thresholds.WarnPercent != defaultWarnPercent {
+		t.Errorf("default warnPercent is %g, want %d", thresholds.WarnPercent, defaultWarnPercent)
+	}
+
+	tests := []struct {
+		name           string
+		reportType     string
+		subType        string
+		reportName     string
+		duration       float64
+		wantStatus     Status
+		wantMaxMinutes float64
+	}{
+		{name: "type only", reportType: "deploy", subType: "addon", reportName: "dns", duration: 10,
+			wantStatus: StatusOK, wantMaxMinutes: 30},
+		{name: "subtype over type", reportType: "deploy", subType: "cluster", reportName: "small", duration: 19,
+			wantStatus: StatusWarn, wantMaxMinutes: 20},
+		{name: "name over subtype", reportType: "deploy", subType: "addon", reportName: "big-cluster", duration: 55,
+			wantStatus: StatusWarn, wantMaxMinutes: 60},
+		{name: "name and subtype", reportType: "deploy", subType: "cluster", reportName: "big-cluster", duration: 51,
+			wantStatus: StatusBreach, wantMaxMinutes: 50},
+		{name: "warn boundary", reportType: "deploy", subType: "addon", duration: 27,
+			wantStatus: StatusWarn, wantMaxMinutes: 30},
+		{name: "maximum is not a breach", reportType: "deploy", subType: "addon", duration: 30,
+			wantStatus: StatusWarn, wantMaxMinutes: 30},
+		{name: "no threshold", reportType: "upgrade", duration: 100, wantStatus: StatusNone},
+	}
+	for _, tt := range tests {
+		status, th := thresholds.Check(tt.reportType, tt.subType, tt.reportName, tt.duration)
+		if status != tt.wantStatus {
+			t.Errorf("%s: status is %q, want %q", tt.name, status, tt.wantStatus)
+		}
+		switch {
+		case tt.wantStatus == StatusNone && th != nil:
+			t.Errorf("%s: threshold %+v matched", tt.name, th)
+		case tt.wantStatus != StatusNone && (th == nil || th.MaxMinutes != tt.wantMaxMinutes):
+			t.Errorf("%s: threshold is %+v, want maxMinutes %g", tt.name, th, tt.wantMaxMinutes)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "warn percent out of range", content: "warnPercent: 150\nthresholds: []\n",
+			wantErr: "warnPercent must be in (0, 100]"},
+		{name: "missing type", content: "thresholds:\n- maxMinutes: 10\n", wantErr: "entry 1: type is required"},
+		{name: "missing maximum", content: "thresholds:\n- type: deploy\n", wantErr: "entry 1: maxMinutes must be positive"},
+		{name: "unknown field", content: "thresholds:\n- type: deploy\n  maxMinutes: 10\n  owner: bob\n",
+			wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeThresholds(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Load error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}